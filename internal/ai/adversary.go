@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// RandomizerAdversary is the name with which the adversarial randomizer is registered, see NewAdversary.
+const RandomizerAdversary = "adversary"
+
+func init() {
+	tetris.RegisterRandomizer(RandomizerAdversary, func(rng *rand.Rand) tetris.Randomizer {
+		return NewAdversary(rng)
+	})
+}
+
+// Adversary is a randomizer that deals the worst tetromino for the board of the game, like Bastet
+// (https://fph.altervista.org/prog/bastet.html), to stress-test how players cope with bad luck.
+// The worst tetromino is the one whose best drop leads to the worst evaluation, which is the minimizing step
+// of the AI's minimax search, see Play. The tetrominoes are chosen for the board as it is when they are dealt
+// at the end of the game's queue, so the tetrominoes already in the queue are not taken into account.
+// The choice is only exact with one preview, in which it is made just before the preceding tetromino is placed.
+// With more previews, each tetromino is chosen for a board that many placements older than the one
+// it is placed on, since the previewed tetrominoes have to be known, so it is less likely to be the worst for it.
+// The zero value of Adversary is not usable, NewAdversary should be used to create one.
+type Adversary struct {
+	// search evaluates the drops with its evaluator, depth and transposition table.
+	// board is the board of the game, on which the tetrominoes are dealt, or nil if it has not been set yet.
+	search *AI
+	board  *tetris.Board
+
+	// rng deals uniformly random tetrominoes before the board is set and breaks ties between equally bad ones.
+	rng *rand.Rand
+}
+
+// NewAdversary creates an adversarial randomizer that breaks ties with random numbers drawn from rng.
+// It evaluates the board after each drop with the default evaluator, see SetEvaluator and SetDepth.
+// Until a board is set with SetBoard, each tetromino is equally likely.
+func NewAdversary(rng *rand.Rand) *Adversary {
+	search := New()
+	search.SetDepth(0)
+
+	return &Adversary{
+		search: search,
+		rng:    rng,
+	}
+}
+
+// SetBoard implements tetris.BoardObserver.
+func (a *Adversary) SetBoard(board *tetris.Board) {
+	a.board = board
+}
+
+// SetEvaluator sets the utility function with which the adversary evaluates the drops of each tetromino.
+func (a *Adversary) SetEvaluator(evaluator *Evaluator) {
+	a.search.SetEvaluator(evaluator)
+}
+
+// SetDepth sets the number of unknown tetrominoes searched after each drop, assuming the worst of them come,
+// like the AI's depth. By default, the depth is 0 and each drop is evaluated by the board after it, like in Bastet.
+// SetDepth panics if the depth is negative.
+func (a *Adversary) SetDepth(depth int) {
+	if depth < 0 {
+		panic(fmt.Errorf("Adversary.SetDepth: invalid depth %d provided", depth))
+	}
+	a.search.SetDepth(depth)
+}
+
+// Next implements tetris.Randomizer.
+func (a *Adversary) Next() tetris.Tetromino {
+	worst := a.worst()
+	return worst[a.rng.Intn(len(worst))]
+}
+
+// Probabilities implements tetris.Distribution. The worst tetrominoes for the board are equally likely.
+func (a *Adversary) Probabilities() [tetris.TetrominoesCount + 1]float64 {
+	var probabilities [tetris.TetrominoesCount + 1]float64
+
+	worst := a.worst()
+	for _, tetromino := range worst {
+		probabilities[tetromino] = 1 / float64(len(worst))
+	}
+	return probabilities
+}
+
+// worst returns the tetrominoes whose best drops on the board are evaluated the worst, in order.
+// Without a board or after the game is over, every tetromino is returned.
+func (a *Adversary) worst() []tetris.Tetromino {
+	if a.board == nil || a.board.GameOver() {
+		return tetris.Tetrominoes()
+	}
+
+	var (
+		worst    []tetris.Tetromino
+		minEval  = maxUtility + 1
+		newBoard = tetris.NewShapeFromBoard(a.board)
+	)
+	for _, tetromino := range tetris.Tetrominoes() {
+		// The drops are evaluated exactly, so that equally bad tetrominoes are recognized.
+		eval := a.search.bestDrop(a.board, newBoard, tetromino, a.search.depth+1, minUtility, maxUtility)
+		if eval < minEval {
+			minEval, worst = eval, []tetris.Tetromino{tetromino}
+		} else if eval == minEval {
+			worst = append(worst, tetromino)
+		}
+	}
+	return worst
+}
@@ -0,0 +1,122 @@
+package ai_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// worstTetrominoes returns the tetrominoes whose best drops on the board are evaluated the worst by the evaluator.
+func worstTetrominoes(board *tetris.Board, evaluator *ai.Evaluator) map[tetris.Tetromino]bool {
+	matrices := tetris.TetrominoMatrices()
+
+	var (
+		worst   map[tetris.Tetromino]bool
+		minEval = math.Inf(1)
+	)
+	for _, tetromino := range tetris.Tetrominoes() {
+		maxEval := ai.MinUtility
+		for rotation := 0; rotation < tetromino.RotationsCount(); rotation++ {
+			for column := 0; column <= board.Width()-len(matrices[tetromino][rotation][0]); column++ {
+				newBoard := tetris.NewBoardFromBoard(board)
+				if err := newBoard.Drop(tetromino, rotation, column); err == nil {
+					maxEval = math.Max(maxEval, evaluator.Evaluate(newBoard))
+				}
+			}
+		}
+
+		if maxEval < minEval {
+			minEval, worst = maxEval, map[tetris.Tetromino]bool{tetromino: true}
+		} else if maxEval == minEval {
+			worst[tetromino] = true
+		}
+	}
+	return worst
+}
+
+func TestAdversaryDealsWorstTetromino(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	adversary := ai.NewAdversary(rand.New(rand.NewSource(1)))
+
+	for i := 0; i < 10; i++ {
+		board := randomBoard(rng, 6, 12)
+		if board.GameOver() {
+			continue
+		}
+		adversary.SetBoard(board)
+
+		worst := worstTetrominoes(board, ai.New().Evaluator())
+		probabilities := adversary.Probabilities()
+		for _, tetromino := range tetris.Tetrominoes() {
+			expected := 0.0
+			if worst[tetromino] {
+				expected = 1 / float64(len(worst))
+			}
+			if probabilities[tetromino] != expected {
+				t.Errorf("board %d: expected probability %f of %s, got %f", i, expected, tetromino, probabilities[tetromino])
+			}
+		}
+
+		for j := 0; j < 10; j++ {
+			if tetromino := adversary.Next(); !worst[tetromino] {
+				t.Errorf("board %d: expected one of the worst tetrominoes %v, got %s", i, worst, tetromino)
+			}
+		}
+	}
+}
+
+func TestAdversaryDealsWorstTetrominoForBoardWhenDealtWithPreviews(t *testing.T) {
+	a := ai.New()
+	a.SetSeed(1)
+	game := player.NewGame(tetris.NewBoard(), a, ai.NewAdversary(rand.New(rand.NewSource(1))), 3)
+
+	for i := 0; i < 30; i++ {
+		if err := game.Play(); err != nil {
+			break
+		}
+
+		// The tetromino dealt at the end of the queue is the worst for the board after the last placement,
+		// not for the board after the tetrominoes before it in the queue are placed.
+		queue := game.Queue()
+		worst := worstTetrominoes(game.Board(), ai.New().Evaluator())
+		if tetromino := queue[len(queue)-1]; !worst[tetromino] {
+			t.Errorf("move %d: expected one of the worst tetrominoes %v, got %s", i, worst, tetromino)
+		}
+	}
+}
+
+func TestAdversaryIsRegistered(t *testing.T) {
+	randomizer, err := tetris.NewRandomizer(ai.RandomizerAdversary, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := randomizer.(*ai.Adversary); !ok {
+		t.Errorf("expected an adversary, got %T", randomizer)
+	}
+}
+
+func TestAdversaryIsHarderThanUniform(t *testing.T) {
+	// play returns the lines that the AI clears in a game of at most 100 tetrominoes dealt by the given randomizer.
+	play := func(randomizer tetris.Randomizer) int {
+		a := ai.New()
+		a.SetSeed(1)
+		game := player.NewGame(tetris.NewBoard(), a, randomizer, 1)
+		for i := 0; i < 100; i++ {
+			if err := game.Play(); err != nil {
+				break
+			}
+		}
+		return game.Board().ClearedLines()
+	}
+
+	adversaryLines := play(ai.NewAdversary(rand.New(rand.NewSource(1))))
+	uniformLines := play(tetris.NewUniformRandomizer(rand.New(rand.NewSource(1))))
+	if adversaryLines >= uniformLines {
+		t.Errorf("expected fewer lines against the adversary than with uniform tetrominoes, got %d and %d",
+			adversaryLines, uniformLines)
+	}
+}
@@ -2,37 +2,158 @@
 package ai
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ozhi/tetris-ai/internal/player"
 	"github.com/ozhi/tetris-ai/internal/tetris"
 )
 
+// Name and ExpectimaxName are the names with which the AI is registered as a player, see player.New,
+// with SearchMinimax and SearchExpectimax. BeamName is the AI that plans the moves of the whole queue with beam search,
+// see AI.SetBeamWidth. The registered AIs use the hold slot.
+const (
+	Name           = "minimax"
+	ExpectimaxName = "expectimax"
+	BeamName       = "beam"
+)
+
+func init() {
+	for name, search := range map[string]Search{Name: SearchMinimax, ExpectimaxName: SearchExpectimax} {
+		search := search
+		player.Register(name, func(seed int64) player.Player {
+			ai := New()
+			ai.SetSeed(seed)
+			ai.SetHoldEnabled(true)
+			ai.SetSearch(search)
+			return ai
+		})
+	}
+
+	player.Register(BeamName, func(seed int64) player.Player {
+		ai := New()
+		ai.SetSeed(seed)
+		ai.SetHoldEnabled(true)
+		ai.SetBeamWidth(defaultBeamWidth)
+		return ai
+	})
+}
+
+// Search is the algorithm with which the AI evaluates boards after tetrominoes that it does not know yet.
+type Search int
+
+const (
+	// SearchMinimax assumes that the worst tetromino for the AI comes next, as if an adversary chose it.
+	SearchMinimax Search = iota
+
+	// SearchExpectimax averages over the tetrominoes that may come next,
+	// weighted by how likely the randomizer is to deal them, see AI.SetRandomizer.
+	SearchExpectimax
+)
+
 // minUtility and maxUtility define the boundaries of AI's evaluation function.
-// The methods utility and evaluate must ony return values in range [minUtility; MaxUtility].
+// Evaluator.Evaluate and AI.evaluate must ony return values in range [minUtility; MaxUtility].
 const (
-	minUtility      = -float64(1e5)
-	maxUtility      = float64(1e5)
-	evaluationDepth = 1
+	minUtility = -float64(1e5)
+	maxUtility = float64(1e5)
 )
 
+// evaluationDepth is the default number of unknown tetrominoes the AI searches after the known ones, see AI.SetDepth.
+const evaluationDepth = 1
+
+// defaultDepth and defaultTimeBudget are the depth and the time budget of the AIs created with New and NewWithSize.
+var (
+	defaultDepth      = evaluationDepth
+	defaultTimeBudget time.Duration
+)
+
+// SetDefaultDepth sets the depth of the AIs created afterwards, including the registered players, see AI.SetDepth.
+// By default, the AIs search one unknown tetromino.
+// SetDefaultDepth panics if the depth is negative.
+func SetDefaultDepth(depth int) {
+	if depth < 0 {
+		panic(fmt.Errorf("SetDefaultDepth: invalid depth %d provided", depth))
+	}
+	defaultDepth = depth
+}
+
+// SetDefaultTimeBudget sets the time budget of the AIs created afterwards, including the registered players,
+// see AI.SetTimeBudget. By default, there is no time budget.
+// SetDefaultTimeBudget panics if the budget is negative.
+func SetDefaultTimeBudget(budget time.Duration) {
+	if budget < 0 {
+		panic(fmt.Errorf("SetDefaultTimeBudget: invalid time budget %s provided", budget))
+	}
+	defaultTimeBudget = budget
+}
+
 // AI encapsulates the artificial intelligence logic.
 // AI has a reference to a tetris board and the next tetromino that should be dropped.
-// By searching the space of potential boards, AI chooses how to rotate and where to drop each tetromino.
-// AI uses the minimax algorithm with alpha beta pruning and a utility function.
+// If the hold slot is enabled, AI may also hold the next tetromino and drop the held one instead.
+// By searching the space of potential boards, AI chooses where to place each tetromino,
+// out of all placements reachable by shifting, rotating and soft dropping it.
+// AI uses the minimax algorithm with alpha beta pruning and a transposition table, or the expectimax algorithm,
+// see Search, and a utility function, see Evaluator.
 // The zero value of AI is not usable, method New should be used to create a struct.
 type AI struct {
 	board    *tetris.Board
 	next     tetris.Tetromino
 	matrices [][]tetris.TetrominoMatrix
+
+	holdEnabled bool
+
+	// search is the algorithm with which unknown tetrominoes are evaluated.
+	// randomizer deals the tetrominoes, so it tells how likely they are for SearchExpectimax.
+	// If it is nil, every tetromino is equally likely.
+	search     Search
+	randomizer tetris.Randomizer
+
+	// depth is the number of unknown tetrominoes searched after the known ones.
+	// timeBudget is the time in which each move is searched, or 0 if the depth is always searched.
+	// completedDepth is the depth of the last completed search and cancelled is set when the search in progress
+	// is cancelled, or nil if it can not be, see PlayContext.
+	// workers is the number of goroutines among which the moves are split.
+	depth          int
+	timeBudget     time.Duration
+	completedDepth int
+	cancelled      *int32
+	workers        int
+
+	// beamWidth is the number of plans kept by the beam search of the whole queue, or 0 if it is not used.
+	beamWidth int
+
+	// evaluator is the utility function with which boards are evaluated.
+	// table caches its evaluations in the minimax search, or is nil if caching is disabled.
+	evaluator *Evaluator
+	table     *table
+
+	// rng breaks ties between equally good moves.
+	rng *rand.Rand
 }
 
-// New returns a pointer to a new AI struct.
+// New returns a pointer to a new AI struct that plays on a board of the default size.
 func New() *AI {
+	return NewWithSize(tetris.DefaultBoardWidth, tetris.DefaultBoardHeight)
+}
+
+// NewWithSize returns a pointer to a new AI struct that plays on a board with the given number of columns and rows.
+// NewWithSize panics if the board size is invalid.
+func NewWithSize(width, height int) *AI {
 	return &AI{
-		board:    tetris.NewBoard(),
-		matrices: tetris.TetrominoMatrices(),
+		board:      tetris.NewBoardWithSize(width, height),
+		matrices:   tetris.TetrominoMatrices(),
+		evaluator:  defaultEvaluator,
+		table:      newTable(DefaultTableSize),
+		depth:      defaultDepth,
+		timeBudget: defaultTimeBudget,
+		workers:    runtime.GOMAXPROCS(0),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -41,6 +162,11 @@ func (ai *AI) Board() *tetris.Board {
 	return ai.board
 }
 
+// Next returns the next tetromino to be dropped by the AI.
+func (ai *AI) Next() tetris.Tetromino {
+	return ai.next
+}
+
 // SetNext sets the next tetromino to be dropped by the AI.
 // SetNext is usually only called once, before dropping the first tetromino.
 // SetNext overwrites if a next tetromino is already set.
@@ -52,8 +178,138 @@ func (ai *AI) SetNext(next tetris.Tetromino) {
 	ai.next = next
 }
 
+// SetHoldEnabled sets whether the AI may use the board's hold slot. The hold slot is disabled by default.
+func (ai *AI) SetHoldEnabled(enabled bool) {
+	ai.holdEnabled = enabled
+}
+
+// SetSearch sets the algorithm with which the AI evaluates boards after unknown tetrominoes.
+// SearchMinimax is used by default.
+func (ai *AI) SetSearch(search Search) {
+	ai.search = search
+}
+
+// Depth returns the number of unknown tetrominoes the AI searches after the known ones.
+func (ai *AI) Depth() int {
+	return ai.depth
+}
+
+// SetDepth sets the number of unknown tetrominoes the AI searches after the known ones.
+// With a time budget, see SetTimeBudget, it is the maximum number searched.
+// By default, the depth set with SetDefaultDepth is used.
+// Each unknown tetromino makes the search tens of times slower.
+// SetDepth panics if the depth is negative.
+func (ai *AI) SetDepth(depth int) {
+	if depth < 0 {
+		panic(fmt.Errorf("AI.SetDepth: invalid depth %d provided", depth))
+	}
+	ai.depth = depth
+}
+
+// CompletedDepth returns the depth with which the last move was chosen, see PlayContext.
+func (ai *AI) CompletedDepth() int {
+	return ai.completedDepth
+}
+
+// TimeBudget returns the time in which the AI searches each move, or 0 if it always searches with its depth.
+func (ai *AI) TimeBudget() time.Duration {
+	return ai.timeBudget
+}
+
+// SetTimeBudget sets the time in which the AI searches each move: the search is deepened iteratively,
+// up to the AI's depth, until the time runs out, see PlayContext. The move may take a little longer than the budget,
+// as the search with depth 0 is always completed. A budget of 0 disables the limit, so that the AI always searches
+// with its depth. By default, the budget set with SetDefaultTimeBudget is used.
+// SetTimeBudget panics if the budget is negative.
+func (ai *AI) SetTimeBudget(budget time.Duration) {
+	if budget < 0 {
+		panic(fmt.Errorf("AI.SetTimeBudget: invalid time budget %s provided", budget))
+	}
+	ai.timeBudget = budget
+}
+
+// SetWorkers sets the number of goroutines among which the AI splits the evaluation of its moves.
+// By default, it is GOMAXPROCS, so that every CPU core is used. The moves do not depend on the number of workers.
+// SetWorkers panics if the number of workers is not positive.
+func (ai *AI) SetWorkers(workers int) {
+	if workers < 1 {
+		panic(fmt.Errorf("AI.SetWorkers: invalid number of workers %d provided", workers))
+	}
+	ai.workers = workers
+}
+
+// SetRandomizer sets the randomizer that deals the tetrominoes to the AI, see player.RandomizerObserver.
+// With SearchExpectimax, the AI weights the tetrominoes that may come next by their probability,
+// see tetris.NextProbabilities. Without a randomizer, every tetromino is considered equally likely.
+func (ai *AI) SetRandomizer(randomizer tetris.Randomizer) {
+	ai.randomizer = randomizer
+}
+
+// Evaluator returns the utility function with which the AI evaluates boards.
+func (ai *AI) Evaluator() *Evaluator {
+	return ai.evaluator
+}
+
+// SetEvaluator sets the utility function with which the AI evaluates boards.
+// By default, the evaluator set with SetDefaultEvaluator is used.
+// SetEvaluator panics if the evaluator is nil.
+func (ai *AI) SetEvaluator(evaluator *Evaluator) {
+	if evaluator == nil {
+		panic(fmt.Errorf("AI.SetEvaluator: nil evaluator provided"))
+	}
+	ai.evaluator = evaluator
+
+	// The cached evaluations were made by the previous evaluator.
+	if ai.table != nil {
+		ai.table.clear()
+	}
+}
+
+// SetTableSize sets the number of entries of the transposition table, in which the minimax search caches
+// evaluations of boards that it reaches in more than one way. The size is rounded up to a power of two
+// and 0 disables the table. The default size is DefaultTableSize. The table does not change the AI's moves.
+// SetTableSize discards the cached evaluations and the table's statistics.
+// SetTableSize panics if the size is negative.
+func (ai *AI) SetTableSize(size int) {
+	if size < 0 {
+		panic(fmt.Errorf("AI.SetTableSize: invalid table size %d provided", size))
+	}
+
+	ai.table = nil
+	if size > 0 {
+		ai.table = newTable(size)
+	}
+}
+
+// TableStats returns the statistics of the lookups in the transposition table since it was created, see SetTableSize.
+// Without a table, the statistics are zero.
+func (ai *AI) TableStats() TableStats {
+	if ai.table == nil {
+		return TableStats{}
+	}
+	return ai.table.stats()
+}
+
+// SetDefaultEvaluator sets the utility function of the AIs created afterwards, including the registered player.
+// By default, an evaluator with DefaultWeights is used.
+// SetDefaultEvaluator panics if the evaluator is nil.
+func SetDefaultEvaluator(evaluator *Evaluator) {
+	if evaluator == nil {
+		panic(fmt.Errorf("SetDefaultEvaluator: nil evaluator provided"))
+	}
+	defaultEvaluator = evaluator
+}
+
+// SetSeed seeds the source of random numbers with which the AI breaks ties between equally good moves.
+// An AI with a given seed always makes the same moves for the same tetrominoes. By default, the time is used as seed.
+func (ai *AI) SetSeed(seed int64) {
+	ai.rng = rand.New(rand.NewSource(seed))
+}
+
 // DropSetNext drops the next tetromino and sets the given tetromino as next.
 // The given next tetromino is taken into consideration.
+// If the hold slot is enabled, the AI considers holding the next tetromino and dropping the held one instead.
+// While the hold slot is empty, DropSetNext fills it with the next tetromino and does not drop anything.
 // DropSetNext returns error if the tetromino is dropped but that leads to game over.
 // DropSetNext panics if the given tetromino is empty or not valid.
 // DropSetNext panics if the board is already in game over state.
@@ -66,133 +322,397 @@ func (ai *AI) DropSetNext(next tetris.Tetromino) error {
 		panic(fmt.Errorf("AI.DropSetNext: can not drop tetromino %s, game is already over", ai.next))
 	}
 
-	type Move struct {
-		rotation int
-		column   int
+	if ai.holdEnabled && ai.board.CanHold() && ai.board.Held() == tetris.TetrominoEmpty {
+		// Holding into an empty slot does not drop anything and only adds options for later drops.
+		ai.board.Hold(ai.next)
+		ai.next = next
+		return nil
+	}
+
+	move, err := ai.Play(ai.board, ai.next, []tetris.Tetromino{next})
+	if err != nil {
+		return fmt.Errorf("AI.DropSetNext: %s", err)
+	}
+
+	if move.Hold {
+		ai.board.Hold(ai.next)
+	}
+	ai.board.Place(move.Placement.Piece)
+
+	ai.next = next
+
+	return nil
+}
+
+// Play implements player.Player. Play does not use or change the AI's own board, which is only played on by DropSetNext.
+// Play places the current tetromino (or the held one) and the first tetromino of the queue in every reachable way
+// and chooses the move after which the board is evaluated best. The rest of the queue is only used by beam search,
+// see SetBeamWidth.
+// The moves are evaluated in parallel, see SetWorkers, and ties between them are broken with the AI's seed.
+// If the hold slot is enabled and empty, the AI always fills it with the current tetromino.
+// If the AI has a time budget, see SetTimeBudget, Play deepens the search until it runs out, see PlayContext.
+func (ai *AI) Play(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) (player.Move, error) {
+	ctx := context.Background()
+	if ai.timeBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ai.timeBudget)
+		defer cancel()
+	}
+	return ai.PlayContext(ctx, board, current, queue)
+}
+
+// PlayContext is like Play, but the search is bounded by the given context.
+// If the context can be done, the search is deepened iteratively: the moves are searched with depth 0, 1, 2 and so on
+// up to the AI's depth, see SetDepth, until the context is done. The move is chosen by the last search that completed.
+// The search with depth 0 is always completed, so that there is a move even if the context is already done.
+// If the context can never be done, e.g. context.Background, the moves are only searched with the AI's depth.
+// With beam search, see SetBeamWidth, the plans are not continued after the context is done.
+func (ai *AI) PlayContext(ctx context.Context, board *tetris.Board, current tetris.Tetromino,
+	queue []tetris.Tetromino) (player.Move, error) {
+	if ai.beamWidth > 0 {
+		return ai.playBeam(ctx, board, current, queue)
+	}
+
+	jobs := ai.jobs(board, current, queue)
+	probabilities := ai.probabilities()
+
+	var bestMoves []player.Move
+	if ctx.Done() == nil {
+		bestMoves = ai.searchMoves(board, current, jobs, ai.depth, &probabilities)
+		ai.completedDepth = ai.depth
+	} else {
+		bestMoves = ai.searchMoves(board, current, jobs, 0, &probabilities)
+		ai.completedDepth = 0
+
+		// The deeper searches are cancelled when the context is done, which discards them.
+		cancelled := new(int32)
+		stop := context.AfterFunc(ctx, func() { atomic.StoreInt32(cancelled, 1) })
+		ai.cancelled = cancelled
+
+		for depth := 1; depth <= ai.depth && ctx.Err() == nil; depth++ {
+			moves := ai.searchMoves(board, current, jobs, depth, &probabilities)
+			if atomic.LoadInt32(cancelled) != 0 {
+				break
+			}
+			bestMoves = moves
+			ai.completedDepth = depth
+		}
+
+		stop()
+		ai.cancelled = nil
+	}
+
+	if len(bestMoves) == 0 {
+		return player.Move{}, fmt.Errorf("AI.Play: can not place tetromino %s, all moves lead to game over", current)
+	}
+
+	return bestMoves[ai.rng.Intn(len(bestMoves))], nil
+}
+
+// candidate is a way to play the current tetromino: the tetromino that is placed, possibly after holding
+// the current one, and the queue of the tetrominoes known to come after it.
+type candidate struct {
+	hold      bool
+	tetromino tetris.Tetromino
+	queue     []tetris.Tetromino
+}
+
+// candidates returns the ways to play the current tetromino on the board, knowing the queue after it.
+// If the hold slot is enabled and empty, the AI always fills it with the current tetromino.
+func (ai *AI) candidates(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) []candidate {
+	if ai.holdEnabled && board.CanHold() && board.Held() == tetris.TetrominoEmpty && len(queue) > 0 {
+		// Holding into an empty slot places the first tetromino of the queue instead.
+		return []candidate{{hold: true, tetromino: queue[0], queue: queue[1:]}}
+	}
+
+	candidates := []candidate{{tetromino: current, queue: queue}}
+	if ai.holdEnabled && board.CanHold() && board.Held() != tetris.TetrominoEmpty {
+		candidates = append(candidates, candidate{hold: true, tetromino: board.Held(), queue: queue})
+	}
+	return candidates
+}
+
+// job is a move, which is evaluated after placing the tetromino known to come next in every reachable way, if any.
+type job struct {
+	move player.Move
+	next tetris.Tetromino
+}
+
+// jobs returns the moves of the current tetromino on the board, knowing the queue after it, see candidates.
+func (ai *AI) jobs(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) []job {
+	var jobs []job
+	for _, candidate := range ai.candidates(board, current, queue) {
+		next := tetris.TetrominoEmpty
+		if len(candidate.queue) > 0 {
+			next = candidate.queue[0]
+		}
+
+		for _, placement := range board.Placements(candidate.tetromino) {
+			jobs = append(jobs, job{
+				move: player.Move{Hold: candidate.hold, Placement: placement},
+				next: next,
+			})
+		}
+	}
+	return jobs
+}
+
+// probabilities returns the probabilities of the unknown tetrominoes for SearchExpectimax.
+// The randomizer has already dealt the known tetrominoes, so its probabilities are those of the first unknown one.
+// Later unknown tetrominoes are assumed to be as likely.
+func (ai *AI) probabilities() [tetris.TetrominoesCount + 1]float64 {
+	if ai.search != SearchExpectimax {
+		return [tetris.TetrominoesCount + 1]float64{}
 	}
+	return tetris.NextProbabilities(ai.randomizer)
+}
+
+// searchMoves evaluates the given moves of the current tetromino on the board with the given depth, see Play,
+// and returns the best ones, in the order of the jobs.
+// If the search is cancelled, the returned moves are meaningless.
+func (ai *AI) searchMoves(board *tetris.Board, current tetris.Tetromino, jobs []job, depth int,
+	probabilities *[tetris.TetrominoesCount + 1]float64) []player.Move {
+	evals, valid := ai.evaluateJobs(board, current, jobs, depth, probabilities, true)
 
 	var (
 		bestEval  = minUtility - 1
-		bestMoves []Move
+		bestMoves []player.Move
 	)
+	for i, job := range jobs {
+		if !valid[i] {
+			continue
+		}
 
-	for curRot := 0; curRot < ai.next.RotationsCount(); curRot++ {
-		curWidth := len(ai.matrices[ai.next][curRot][0])
-		for curCol := 0; curCol <= ai.board.Width()-curWidth; curCol++ {
-			curMove := Move{
-				rotation: curRot,
-				column:   curCol,
-			}
+		if evals[i] > bestEval {
+			bestEval = evals[i]
+			bestMoves = []player.Move{job.move}
+		} else if evals[i] == bestEval {
+			bestMoves = append(bestMoves, job.move)
+		}
+	}
 
-			curBoard := tetris.NewBoardFromBoard(ai.board)
-			if err := curBoard.Drop(ai.next, curRot, curCol); err != nil {
-				continue // curBoard's game has just ended.
-			}
+	return bestMoves
+}
 
-			for nextRot := 0; nextRot < next.RotationsCount(); nextRot++ {
-				nextWidth := len(ai.matrices[next][nextRot][0])
-				for nextCol := 0; nextCol <= ai.board.Width()-nextWidth; nextCol++ {
-					nextBoard := tetris.NewBoardFromBoard(curBoard)
-					if err := nextBoard.Drop(next, nextRot, nextCol); err != nil {
-						continue // curBoard's game has just ended.
-					}
+// evaluateJobs evaluates the given moves of the current tetromino on the board with the given depth in parallel
+// and returns their evaluations and whether they are valid, i.e. the tetromino known to come next can be placed.
+// If prune is true, the moves that are worse than the best one are pruned and their evaluations are only upper bounds,
+// otherwise the evaluations of all moves are exact.
+func (ai *AI) evaluateJobs(board *tetris.Board, current tetris.Tetromino, jobs []job, depth int,
+	probabilities *[tetris.TetrominoesCount + 1]float64, prune bool) ([]float64, []bool) {
+	// bound is the best evaluation of a move so far, shared by all workers. Moves that are worse are pruned,
+	// but moves as good as the best one are evaluated exactly, so that the best moves do not depend on the order
+	// in which the workers evaluate them. Without pruning, the bound is never raised.
+	bound := newSharedMax(minUtility - 1)
+
+	evaluate := func(board *tetris.Board, lower float64) float64 {
+		if ai.search == SearchExpectimax {
+			return ai.expectimax(board, depth, probabilities)
+		}
+		return ai.evaluate(board, depth, math.Nextafter(lower, math.Inf(-1)), maxUtility)
+	}
+
+	var (
+		evals = make([]float64, len(jobs))
+		valid = make([]bool, len(jobs))
+
+		// claimed is the index of the last job claimed by a worker.
+		claimed = int64(-1)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < ai.workers && w < len(jobs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-					eval := ai.evaluate(nextBoard, evaluationDepth, bestEval, maxUtility)
-					if eval > bestEval {
-						bestEval = eval
-						bestMoves = []Move{curMove}
-					} else if eval == bestEval {
-						bestMoves = append(bestMoves, curMove)
+			// The boards are only allocated once per worker and reused for every move.
+			curBoard := tetris.NewShapeFromBoard(board)
+			nextBoard := tetris.NewShapeFromBoard(board)
+
+			for i := int(atomic.AddInt64(&claimed, 1)); i < len(jobs); i = int(atomic.AddInt64(&claimed, 1)) {
+				if ai.isCancelled() {
+					return
+				}
+				job := jobs[i]
+
+				curBoard.CopyFrom(board)
+				if job.move.Hold {
+					curBoard.Hold(current)
+				}
+				curBoard.Lock(job.move.Placement.Piece)
+
+				if job.next == tetris.TetrominoEmpty {
+					evals[i], valid[i] = evaluate(curBoard, bound.get()), true
+					if prune {
+						bound.raise(evals[i])
 					}
+					continue
+				}
+
+				// If there are no placements for the next tetromino, curBoard's game is over and the move is not valid.
+				evals[i] = minUtility - 1
+				for _, nextPlacement := range curBoard.Placements(job.next) {
+					nextBoard.CopyFrom(curBoard)
+					nextBoard.Lock(nextPlacement.Piece)
+
+					evals[i] = math.Max(evals[i], evaluate(nextBoard, math.Max(bound.get(), evals[i])))
+					valid[i] = true
+				}
+				if prune {
+					bound.raise(evals[i])
 				}
 			}
-		}
+		}()
 	}
+	wg.Wait()
 
-	if len(bestMoves) == 0 {
-		return fmt.Errorf("AI.DropSetNext: can not drop tetromino %s, all moves lead to game over", ai.next)
-	}
+	return evals, valid
+}
 
-	move := bestMoves[rand.Intn(len(bestMoves))]
-	if err := ai.board.Drop(ai.next, move.rotation, move.column); err != nil {
-		return fmt.Errorf("AI.Drop: could not drop: %s", err)
-	}
+// isCancelled returns whether the search in progress is cancelled, see PlayContext.
+func (ai *AI) isCancelled() bool {
+	return ai.cancelled != nil && atomic.LoadInt32(ai.cancelled) != 0
+}
 
-	ai.next = next
+// sharedMax is a float64 that can only be raised, safe for concurrent use.
+type sharedMax struct {
+	bits uint64
+}
 
-	return nil
+// newSharedMax returns a sharedMax with the given initial value.
+func newSharedMax(value float64) *sharedMax {
+	return &sharedMax{bits: math.Float64bits(value)}
+}
+
+// get returns the current value.
+func (m *sharedMax) get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&m.bits))
+}
+
+// raise sets the value to the given one, if it is greater.
+func (m *sharedMax) raise(value float64) {
+	for {
+		old := atomic.LoadUint64(&m.bits)
+		if value <= math.Float64frombits(old) || atomic.CompareAndSwapUint64(&m.bits, old, math.Float64bits(value)) {
+			return
+		}
+	}
 }
 
 // evaluate returns an evaluation of the given board
-// It uses the minimax algorithm https://en.wikipedia.org/wiki/Minimax
-// with alpha-beta pruning and maximum depth.
+// It uses the minimax algorithm https://en.wikipedia.org/wiki/Minimax with maximum depth:
+// the evaluation is the worst, out of all tetrominoes, of the best evaluations after dropping the tetromino.
+// Unlike the current and next tetromino, which are placed anywhere they can reach, the unknown tetrominoes
+// are only dropped straight down, which is much cheaper and good enough for estimating how good the board is.
+// A drop that ends the game is evaluated as minUtility.
+//
+// The search is pruned with fail-soft alpha-beta pruning: if the evaluation is at most alpha, the returned value
+// is between the evaluation and alpha, if it is at least beta, the returned value is between beta and the evaluation,
+// and otherwise the returned value is exact.
 // Returned evaluation is in the range [minUtility; maxUtility] and greater means more desirable for the AI.
 func (ai *AI) evaluate(board *tetris.Board, depth int, alpha, beta float64) float64 {
 	if depth == 0 || board.GameOver() {
-		return utility(board)
+		return ai.evaluator.Evaluate(board)
 	}
 
-	minEval := maxUtility + 1
-	for _, tetromino := range tetris.Tetrominoes() {
-		maxEval := minUtility - 1
-		for rotation := 0; rotation < tetromino.RotationsCount(); rotation++ {
-			tetrominoWidth := len(ai.matrices[tetromino][rotation][0])
-			for column := 0; column <= board.Width()-tetrominoWidth; column++ {
-				newBoard := tetris.NewBoardFromBoard(board)
-				if err := newBoard.Drop(tetromino, rotation, column); err != nil {
-					// newBoard's game has just ended. Ignore.
-				}
+	newBoard := tetris.NewShapeFromBoard(board)
 
-				eval := ai.evaluate(newBoard, depth-1, alpha, beta)
-				maxEval = math.Max(maxEval, eval)
-				alpha = math.Max(alpha, maxEval)
-				if alpha > beta {
-					break
-				}
-			}
+	minEval := maxUtility
+	for _, tetromino := range tetris.Tetrominoes() {
+		minEval = math.Min(minEval, ai.bestDrop(board, newBoard, tetromino, depth, alpha, beta))
+		if minEval <= alpha {
+			// The board is no better than one that can already be reached, so the rest of the tetrominoes do not matter.
+			return minEval
 		}
-
-		minEval = math.Min(minEval, maxEval)
 		beta = math.Min(beta, minEval)
-		if alpha > beta {
-			break
-		}
 	}
 	return minEval
 }
 
-// utility returns a heuristical evaluation of the given board in the range [minUtility; maxUtility].
-// Greater utility means more desirable board for the AI.
-func utility(board *tetris.Board) float64 {
-	if board.GameOver() {
-		return minUtility
+// bestDrop returns the evaluation of the best drop of the given tetromino on the board, see evaluate.
+// newBoard is a board of the same size, which is overwritten with each drop.
+// The evaluation is looked up in the transposition table, if there is one, and otherwise stored in it.
+func (ai *AI) bestDrop(board, newBoard *tetris.Board, tetromino tetris.Tetromino, depth int, alpha, beta float64) float64 {
+	if ai.table == nil {
+		return ai.searchDrops(board, newBoard, tetromino, depth, alpha, beta)
 	}
 
-	var (
-		columnHeights = board.HeightsByColumn()
-		columnHoles   = board.HolesByColumn()
+	key := tableKey(board, tetromino, depth)
+	if eval, ok := ai.table.lookup(key, alpha, beta); ok {
+		return eval
+	}
 
-		heightsSum  int
-		heightsDiff int
-		holes       int
-	)
+	eval := ai.searchDrops(board, newBoard, tetromino, depth, alpha, beta)
+	if !ai.isCancelled() {
+		// The evaluation of a cancelled search is meaningless.
+		ai.table.store(key, eval, alpha, beta)
+	}
+	return eval
+}
+
+// searchDrops evaluates every drop of the given tetromino on the board, see bestDrop.
+func (ai *AI) searchDrops(board, newBoard *tetris.Board, tetromino tetris.Tetromino, depth int, alpha, beta float64) float64 {
+	maxEval := minUtility
+	for rotation := 0; rotation < tetromino.RotationsCount(); rotation++ {
+		tetrominoWidth := len(ai.matrices[tetromino][rotation][0])
+		for column := 0; column <= board.Width()-tetrominoWidth; column++ {
+			if ai.isCancelled() {
+				return maxEval
+			}
+			newBoard.CopyFrom(board)
+
+			eval := minUtility
+			if err := newBoard.Drop(tetromino, rotation, column); err == nil {
+				eval = ai.evaluate(newBoard, depth-1, alpha, beta)
+			}
 
-	for col := 0; col < board.Width(); col++ {
-		heightsSum += columnHeights[col]
-		holes += columnHoles[col]
-		if col != 0 {
-			heightsDiff += int(math.Abs(float64(columnHeights[col] - columnHeights[col-1])))
+			maxEval = math.Max(maxEval, eval)
+			if maxEval >= beta {
+				// The tetromino is no worse than another one, so the rest of the drops do not matter.
+				return maxEval
+			}
+			alpha = math.Max(alpha, maxEval)
 		}
 	}
+	return maxEval
+}
+
+// expectimax returns an evaluation of the given board
+// It uses the expectimax algorithm https://en.wikipedia.org/wiki/Expectiminimax with maximum depth:
+// the evaluation is the average of the best evaluations after each tetromino, weighted by the given probabilities.
+// Tetrominoes that can not come next are not searched. Like in evaluate, the unknown tetrominoes are only dropped
+// and a drop that ends the game is evaluated as minUtility.
+// Returned evaluation is in the range [minUtility; maxUtility] and greater means more desirable for the AI.
+func (ai *AI) expectimax(board *tetris.Board, depth int, probabilities *[tetris.TetrominoesCount + 1]float64) float64 {
+	if depth == 0 || board.GameOver() {
+		return ai.evaluator.Evaluate(board)
+	}
+
+	newBoard := tetris.NewShapeFromBoard(board)
+
+	var expectedEval float64
+	for _, tetromino := range tetris.Tetrominoes() {
+		if probabilities[tetromino] == 0 {
+			continue
+		}
 
-	utility := -0.510066*float64(heightsSum) +
-		0.760666*float64(board.ClearedLines()) +
-		-0.35663*float64(holes) +
-		-0.184483*float64(heightsDiff)
+		maxEval := minUtility
+		for rotation := 0; rotation < tetromino.RotationsCount(); rotation++ {
+			tetrominoWidth := len(ai.matrices[tetromino][rotation][0])
+			for column := 0; column <= board.Width()-tetrominoWidth; column++ {
+				if ai.isCancelled() {
+					return minUtility
+				}
+				newBoard.CopyFrom(board)
+				if err := newBoard.Drop(tetromino, rotation, column); err == nil {
+					maxEval = math.Max(maxEval, ai.expectimax(newBoard, depth-1, probabilities))
+				}
+			}
+		}
 
-	if utility < minUtility || utility > maxUtility {
-		panic(fmt.Errorf("Invalid utility %f returned", utility))
+		expectedEval += probabilities[tetromino] * maxEval
 	}
 
-	return utility
+	// The probabilities may not add up to exactly 1, which must not take the evaluation out of range.
+	return math.Max(minUtility, math.Min(maxUtility, expectedEval))
 }
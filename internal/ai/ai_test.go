@@ -2,9 +2,11 @@ package ai_test
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
 	"github.com/ozhi/tetris-ai/internal/tetris"
 )
 
@@ -26,6 +28,127 @@ func ExampleAI() {
 	// Output: 4
 }
 
+func TestAIPlaysOnBoardsOfDifferentSizes(t *testing.T) {
+	sizes := []struct {
+		width  int
+		height int
+	}{
+		{width: 4, height: 20},
+		{width: 6, height: 20},
+		{width: 10, height: 40},
+	}
+
+	for _, size := range sizes {
+		ai := ai.NewWithSize(size.width, size.height)
+		ai.SetNext(tetris.TetrominoI)
+
+		for i := 0; i < 10; i++ {
+			if err := ai.DropSetNext(tetris.Tetrominoes()[i%tetris.TetrominoesCount]); err != nil {
+				t.Fatalf("%dx%d board: unexpected game over: %s", size.width, size.height, err)
+			}
+		}
+
+		board := ai.Board()
+		if board.Width() != size.width || board.Height() != size.height {
+			t.Errorf("expected %dx%d board, got %dx%d", size.width, size.height, board.Width(), board.Height())
+		}
+		if board.DroppedTetrominoes() != 10 {
+			t.Errorf("%dx%d board: expected 10 dropped tetrominoes, got %d", size.width, size.height, board.DroppedTetrominoes())
+		}
+	}
+}
+
+func TestAIUsesHoldSlotWhenEnabled(t *testing.T) {
+	ai := ai.New()
+	ai.SetHoldEnabled(true)
+	ai.SetNext(tetris.TetrominoS)
+
+	for i := 0; i < 20; i++ {
+		if err := ai.DropSetNext(tetris.Tetrominoes()[i%tetris.TetrominoesCount]); err != nil {
+			t.Fatalf("unexpected game over: %s", err)
+		}
+	}
+
+	board := ai.Board()
+	if board.Held() == tetris.TetrominoEmpty {
+		t.Errorf("expected the hold slot to be filled")
+	}
+	if board.DroppedTetrominoes() != 19 {
+		t.Errorf("expected 19 dropped tetrominoes, got %d", board.DroppedTetrominoes())
+	}
+}
+
+func TestAIWithSeedIsDeterministic(t *testing.T) {
+	play := func() *tetris.Board {
+		randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(42)), 1)
+
+		ai := ai.New()
+		ai.SetSeed(42)
+		ai.SetHoldEnabled(true)
+		ai.SetNext(randomizer.Next())
+
+		for i := 0; i < 30; i++ {
+			if err := ai.DropSetNext(randomizer.Next()); err != nil {
+				t.Fatalf("unexpected game over: %s", err)
+			}
+		}
+		return ai.Board()
+	}
+
+	first, second := play(), play()
+	for row := 0; row < first.Height(); row++ {
+		for col := 0; col < first.Width(); col++ {
+			if first.At(row, col) != second.At(row, col) {
+				t.Fatalf("expected the same boards, cell (%d, %d) differs", row, col)
+			}
+		}
+	}
+	if first.Score() != second.Score() {
+		t.Errorf("expected the same scores, got %d and %d", first.Score(), second.Score())
+	}
+}
+
+func TestAIPlaysGameAsPlayer(t *testing.T) {
+	for _, name := range []string{ai.Name, ai.ExpectimaxName, ai.BeamName} {
+		p, err := player.New(name, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+		game := player.NewGame(tetris.NewBoard(), p, randomizer, 1)
+		for i := 0; i < 50; i++ {
+			if err := game.Play(); err != nil {
+				t.Fatalf("%s: unexpected game over: %s", name, err)
+			}
+		}
+
+		// Holding the first tetromino into the empty slot also places the next one, so every play drops a tetromino.
+		board := game.Board()
+		if board.DroppedTetrominoes() != 50 || board.Held() == tetris.TetrominoEmpty {
+			t.Errorf("%s: expected 50 dropped tetrominoes and a held one, got %d and %s",
+				name, board.DroppedTetrominoes(), board.Held())
+		}
+	}
+}
+
+func TestAIExpectimaxWithoutRandomizer(t *testing.T) {
+	// Without a randomizer, every tetromino is equally likely.
+	a := ai.New()
+	a.SetSearch(ai.SearchExpectimax)
+	a.SetSeed(1)
+	a.SetNext(tetris.TetrominoT)
+
+	for i := 0; i < 20; i++ {
+		if err := a.DropSetNext(tetris.Tetrominoes()[i%tetris.TetrominoesCount]); err != nil {
+			t.Fatalf("unexpected game over: %s", err)
+		}
+	}
+	if a.Board().ClearedLines() == 0 {
+		t.Errorf("expected cleared lines")
+	}
+}
+
 func benchmarkDropSetNext(tetrominoesToDrop int, b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		ai := ai.New() // Start with a fresh board each time.
@@ -58,3 +181,79 @@ func BenchmarkDropSetNext1000(b *testing.B) {
 func BenchmarkDropSetNext10000(b *testing.B) {
 	benchmarkDropSetNext(10000, b)
 }
+
+// benchmarkGame plays a game of 200 tetrominoes dealt by the randomizer with the given name
+// with the registered player with the given name, for each iteration, and reports the average lines and score.
+func benchmarkGame(playerName, randomizerName string, b *testing.B) {
+	var lines, score int
+	for i := 0; i < b.N; i++ {
+		p, _ := player.New(playerName, int64(i))
+		randomizer, _ := tetris.NewRandomizer(randomizerName, rand.New(rand.NewSource(int64(i))))
+		game := player.NewGame(tetris.NewBoard(), p, randomizer, 1)
+
+		for t := 0; t < 200; t++ {
+			if err := game.Play(); err != nil {
+				break
+			}
+		}
+
+		lines += game.Board().ClearedLines()
+		score += game.Board().Score()
+	}
+
+	b.ReportMetric(float64(lines)/float64(b.N), "lines/game")
+	b.ReportMetric(float64(score)/float64(b.N), "score/game")
+}
+
+func BenchmarkMinimaxUniform(b *testing.B) {
+	benchmarkGame(ai.Name, tetris.RandomizerUniform, b)
+}
+
+func BenchmarkExpectimaxUniform(b *testing.B) {
+	benchmarkGame(ai.ExpectimaxName, tetris.RandomizerUniform, b)
+}
+
+func BenchmarkMinimax7Bag(b *testing.B) {
+	benchmarkGame(ai.Name, tetris.Randomizer7Bag, b)
+}
+
+func BenchmarkExpectimax7Bag(b *testing.B) {
+	benchmarkGame(ai.ExpectimaxName, tetris.Randomizer7Bag, b)
+}
+
+// benchmarkTable plays a game of 30 tetrominoes with depth 2 and a transposition table of the given size,
+// for each iteration, and reports the table's hit rate.
+func benchmarkTable(size int, b *testing.B) {
+	var stats ai.TableStats
+	for i := 0; i < b.N; i++ {
+		a := ai.New()
+		a.SetSeed(int64(i))
+		a.SetDepth(2)
+		a.SetTableSize(size)
+
+		randomizer := tetris.NewUniformRandomizer(rand.New(rand.NewSource(int64(i))))
+		game := player.NewGame(tetris.NewBoard(), a, randomizer, 1)
+		for t := 0; t < 30; t++ {
+			if err := game.Play(); err != nil {
+				break
+			}
+		}
+
+		stats.Hits += a.TableStats().Hits
+		stats.Misses += a.TableStats().Misses
+	}
+
+	b.ReportMetric(stats.HitRate(), "hits/lookup")
+}
+
+func BenchmarkWithoutTable(b *testing.B) {
+	benchmarkTable(0, b)
+}
+
+func BenchmarkWithTable(b *testing.B) {
+	benchmarkTable(ai.DefaultTableSize, b)
+}
+
+func BenchmarkMCTS7Bag(b *testing.B) {
+	benchmarkGame(ai.MCTSName, tetris.Randomizer7Bag, b)
+}
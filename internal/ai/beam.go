@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// DefaultBeamWidth is the default beam width of the AI registered as BeamName, see SetDefaultBeamWidth.
+const DefaultBeamWidth = 16
+
+// defaultBeamWidth is the beam width of the AIs registered as BeamName.
+var defaultBeamWidth = DefaultBeamWidth
+
+// SetDefaultBeamWidth sets the beam width of the AIs registered as BeamName that are created afterwards,
+// see AI.SetBeamWidth. By default, it is DefaultBeamWidth.
+// SetDefaultBeamWidth panics if the width is not positive, as the registered AIs always use beam search.
+func SetDefaultBeamWidth(width int) {
+	if width < 1 {
+		panic(fmt.Errorf("SetDefaultBeamWidth: invalid beam width %d provided", width))
+	}
+	defaultBeamWidth = width
+}
+
+// BeamWidth returns the number of plans the AI keeps in its beam search, or 0 if it does not use beam search.
+func (ai *AI) BeamWidth() int {
+	return ai.beamWidth
+}
+
+// SetBeamWidth makes the AI plan the moves of all known tetrominoes, the current one and the whole queue,
+// with beam search: after placing each tetromino in every reachable way, only the given number of best boards
+// are kept to place the next tetromino on. The plans are evaluated with the AI's evaluator, see SetEvaluator,
+// and the first move of the best plan is played. The tetrominoes after the queue are not searched.
+// A width of 0 disables beam search, which is the default, so that the AI searches with its depth instead, see Play.
+// SetBeamWidth panics if the width is negative.
+func (ai *AI) SetBeamWidth(width int) {
+	if width < 0 {
+		panic(fmt.Errorf("AI.SetBeamWidth: invalid beam width %d provided", width))
+	}
+	ai.beamWidth = width
+}
+
+// plan is a board reached by placing some of the known tetrominoes, which is kept in the beam.
+type plan struct {
+	board *tetris.Board
+
+	// current is the tetromino to be placed next on the board and queue contains the ones after it.
+	// If all known tetrominoes have been placed, current is TetrominoEmpty.
+	current tetris.Tetromino
+	queue   []tetris.Tetromino
+
+	// first is the move of the current tetromino with which the plan starts and index is its index out of all
+	// first moves, in the order of the candidates and their placements. eval is the evaluation of the board.
+	first player.Move
+	index int
+	eval  float64
+}
+
+// planKey identifies plans that can be continued in the same ways, of which the beam only keeps the best one.
+type planKey struct {
+	hash    uint64
+	lines   int
+	held    tetris.Tetromino
+	current tetris.Tetromino
+	queued  int
+}
+
+// playBeam returns the best move of the current tetromino on the board, see SetBeamWidth.
+// If the context is done, the plans are not continued and the best move of the ones made so far is returned.
+// Ties between equally good moves are broken with the AI's seed.
+func (ai *AI) playBeam(ctx context.Context, board *tetris.Board, current tetris.Tetromino,
+	queue []tetris.Tetromino) (player.Move, error) {
+	beam := []plan{{board: board, current: current, queue: queue}}
+
+	for step := 0; step == 0 || ctx.Err() == nil; step++ {
+		var (
+			plans    []plan
+			extended bool
+		)
+		for _, p := range beam {
+			if p.current == tetris.TetrominoEmpty || p.board.GameOver() {
+				plans = append(plans, p)
+				continue
+			}
+
+			for _, candidate := range ai.candidates(p.board, p.current, p.queue) {
+				for _, placement := range p.board.Placements(candidate.tetromino) {
+					next := plan{board: tetris.NewShapeFromBoard(p.board), first: p.first, index: p.index}
+					if candidate.hold {
+						next.board.Hold(p.current)
+					}
+					next.board.Lock(placement.Piece)
+					next.eval = ai.evaluator.Evaluate(next.board)
+
+					if step == 0 {
+						next.first, next.index = player.Move{Hold: candidate.hold, Placement: placement}, len(plans)
+					}
+					if len(candidate.queue) > 0 {
+						next.current, next.queue = candidate.queue[0], candidate.queue[1:]
+					}
+
+					plans = append(plans, next)
+					extended = true
+				}
+			}
+		}
+
+		if !extended {
+			break
+		}
+		beam = ai.prune(plans)
+	}
+
+	// The best moves are ordered by their index, like in Play, and each is only taken once.
+	var (
+		bestEval = minUtility - 1
+		best     = make(map[int]player.Move)
+	)
+	for _, p := range beam {
+		if p.first.Placement.Piece.Tetromino == tetris.TetrominoEmpty {
+			// The current tetromino could not be placed anywhere.
+			continue
+		}
+
+		if p.eval > bestEval {
+			bestEval = p.eval
+			best = map[int]player.Move{p.index: p.first}
+		} else if p.eval == bestEval {
+			best[p.index] = p.first
+		}
+	}
+
+	indexes := make([]int, 0, len(best))
+	for index := range best {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	bestMoves := make([]player.Move, len(indexes))
+	for i, index := range indexes {
+		bestMoves[i] = best[index]
+	}
+
+	if len(bestMoves) == 0 {
+		return player.Move{}, fmt.Errorf("AI.Play: can not place tetromino %s, there are no moves", current)
+	}
+
+	return bestMoves[ai.rng.Intn(len(bestMoves))], nil
+}
+
+// prune returns the best plans, up to the AI's beam width, without plans that can be continued in the same ways
+// as better ones. Equally good plans are kept in the given order, so that the search is deterministic.
+func (ai *AI) prune(plans []plan) []plan {
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].eval > plans[j].eval
+	})
+
+	var (
+		beam = make([]plan, 0, ai.beamWidth)
+		seen = make(map[planKey]bool)
+	)
+	for _, p := range plans {
+		if len(beam) == ai.beamWidth {
+			break
+		}
+
+		key := planKey{
+			hash:    p.board.Hash(),
+			lines:   p.board.ClearedLines(),
+			held:    p.board.Held(),
+			current: p.current,
+			queued:  len(p.queue),
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		beam = append(beam, p)
+	}
+
+	return beam
+}
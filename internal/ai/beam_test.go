@@ -0,0 +1,104 @@
+package ai_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+func TestAIBeamWithoutQueueEqualsSearchWithoutDepth(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+
+	// Without a queue, the beam search only places the current tetromino, like the search with depth 0.
+	for i := 0; i < 20; i++ {
+		board := randomBoard(rng, 6, 12)
+		current := tetris.Tetrominoes()[rng.Intn(tetris.TetrominoesCount)]
+		if len(board.Placements(current)) == 0 {
+			continue
+		}
+
+		var pieces []tetris.Piece
+		for _, width := range []int{0, 1, 8} {
+			a := ai.NewWithSize(6, 12)
+			a.SetSeed(int64(i))
+			a.SetDepth(0)
+			a.SetBeamWidth(width)
+
+			move, err := a.Play(board, current, nil)
+			if err != nil {
+				t.Fatalf("board %d: unexpected error: %s", i, err)
+			}
+			pieces = append(pieces, move.Placement.Piece)
+		}
+
+		for _, piece := range pieces[1:] {
+			if piece != pieces[0] {
+				t.Errorf("board %d: expected piece %v, got %v", i, pieces[0], piece)
+			}
+		}
+	}
+}
+
+func TestAIBeamPlansWholeQueue(t *testing.T) {
+	// The bottom rows are cleared by stacking both O pieces next to the gap that the I fills afterwards:
+	//   X...
+	//   X...
+	//   X...
+	//   X...
+	// Only the whole queue shows that, with the first tetromino of the queue, no placement of the O clears lines.
+	board := tetris.NewBoardWithSize(4, 8)
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoI, Orientation: tetris.OrientationRight, Row: 4, Col: -2})
+
+	a := ai.NewWithSize(4, 8)
+	a.SetSeed(1)
+	a.SetBeamWidth(ai.DefaultBeamWidth)
+	a.SetEvaluator(linesEvaluator(t))
+
+	plays := []struct {
+		current tetris.Tetromino
+		queue   []tetris.Tetromino
+	}{
+		{current: tetris.TetrominoO, queue: []tetris.Tetromino{tetris.TetrominoO, tetris.TetrominoI}},
+		{current: tetris.TetrominoO, queue: []tetris.Tetromino{tetris.TetrominoI}},
+		{current: tetris.TetrominoI},
+	}
+	for _, play := range plays {
+		move, err := a.PlayContext(context.Background(), board, play.current, play.queue)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		board.Lock(move.Placement.Piece)
+	}
+
+	if board.ClearedLines() != 4 {
+		t.Errorf("expected 4 cleared lines, got %d", board.ClearedLines())
+	}
+}
+
+// linesEvaluator returns an evaluator that only takes into account the cleared lines.
+func linesEvaluator(t *testing.T) *ai.Evaluator {
+	evaluator, err := ai.NewEvaluator(ai.Weights{"lines": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return evaluator
+}
+
+func TestAIBeamPlaysWithPreviews(t *testing.T) {
+	p, _ := player.New(ai.BeamName, 1)
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	game := player.NewGame(tetris.NewBoard(), p, randomizer, 5)
+
+	for i := 0; i < 100; i++ {
+		if err := game.Play(); err != nil {
+			t.Fatalf("unexpected game over: %s", err)
+		}
+	}
+	if game.Board().ClearedLines() < 30 {
+		t.Errorf("expected at least 30 cleared lines, got %d", game.Board().ClearedLines())
+	}
+}
@@ -0,0 +1,297 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Feature is a property of a board that the AI takes into account when evaluating it, e.g. the number of holes.
+type Feature func(board *tetris.Board) float64
+
+// features contains the features that can be weighted by name.
+// The features used by Dellacherie's and El-Tetris's weights, see NamedWeights, are computed by the board.
+var features = map[string]Feature{
+	"height":    aggregateHeight,
+	"lines":     clearedLines,
+	"holes":     holes,
+	"bumpiness": bumpiness,
+
+	"landing-height":     func(board *tetris.Board) float64 { return board.LandingHeight() },
+	"eroded-cells":       func(board *tetris.Board) float64 { return float64(board.ErodedPieceCells()) },
+	"row-transitions":    func(board *tetris.Board) float64 { return float64(board.RowTransitions()) },
+	"column-transitions": func(board *tetris.Board) float64 { return float64(board.ColumnTransitions()) },
+	"wells":              func(board *tetris.Board) float64 { return float64(board.CumulativeWells()) },
+	"max-well-depth":     func(board *tetris.Board) float64 { return float64(board.MaxWellDepth()) },
+	"hole-depth":         func(board *tetris.Board) float64 { return float64(board.HoleDepth()) },
+	"rows-with-holes":    func(board *tetris.Board) float64 { return float64(board.RowsWithHoles()) },
+}
+
+// FeatureNames returns the sorted names of the features that can be weighted.
+func FeatureNames() []string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aggregateHeight returns the sum of the heights of the board's columns.
+func aggregateHeight(board *tetris.Board) float64 {
+	var sum int
+	for _, height := range board.HeightsByColumn() {
+		sum += height
+	}
+	return float64(sum)
+}
+
+// clearedLines returns the number of lines cleared in the board's game.
+func clearedLines(board *tetris.Board) float64 {
+	return float64(board.ClearedLines())
+}
+
+// holes returns the number of empty cells on the board that have an occupied cell above them.
+func holes(board *tetris.Board) float64 {
+	var sum int
+	for _, holes := range board.HolesByColumn() {
+		sum += holes
+	}
+	return float64(sum)
+}
+
+// bumpiness returns the sum of the differences between the heights of neighbouring columns.
+func bumpiness(board *tetris.Board) float64 {
+	var (
+		heights = board.HeightsByColumn()
+		sum     int
+	)
+	for col := 1; col < len(heights); col++ {
+		diff := heights[col] - heights[col-1]
+		if diff < 0 {
+			diff = -diff
+		}
+		sum += diff
+	}
+	return float64(sum)
+}
+
+// Weights maps feature names, see FeatureNames, to the coefficients with which they are taken into account.
+// Features with no weight are not taken into account.
+type Weights map[string]float64
+
+// DefaultWeights returns the weights that the AI uses by default, chosen with trial and error.
+func DefaultWeights() Weights {
+	return Weights{
+		"height":    -0.510066,
+		"lines":     0.760666,
+		"holes":     -0.35663,
+		"bumpiness": -0.184483,
+	}
+}
+
+// Names of the weight sets, see NamedWeights.
+const (
+	WeightsDefault     = "default"
+	WeightsDellacherie = "dellacherie"
+	WeightsElTetris    = "el-tetris"
+)
+
+// WeightsNames returns the names of all weight sets, see NamedWeights.
+func WeightsNames() []string {
+	return []string{WeightsDefault, WeightsDellacherie, WeightsElTetris}
+}
+
+// NamedWeights returns the weight set with the given name:
+//   - WeightsDefault is DefaultWeights.
+//   - WeightsDellacherie is Pierre Dellacherie's hand-tuned weights.
+//   - WeightsElTetris is El-Tetris's weights, which improve on Dellacherie's with a genetic algorithm,
+//     see https://imake.ninja/el-tetris-an-improvement-on-pierre-dellacheries-algorithm/.
+//
+// NamedWeights returns error if there is no weight set with that name.
+func NamedWeights(name string) (Weights, error) {
+	switch name {
+	case WeightsDefault:
+		return DefaultWeights(), nil
+
+	case WeightsDellacherie:
+		return Weights{
+			"landing-height":     -1,
+			"eroded-cells":       1,
+			"row-transitions":    -1,
+			"column-transitions": -1,
+			"holes":              -4,
+			"wells":              -1,
+		}, nil
+
+	case WeightsElTetris:
+		return Weights{
+			"landing-height":     -4.500158825082766,
+			"eroded-cells":       3.4181268101392694,
+			"row-transitions":    -3.2178882868487753,
+			"column-transitions": -9.348695305445199,
+			"holes":              -7.899265427351652,
+			"wells":              -3.3855972247263626,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("NamedWeights: unknown weights %q, expected one of: %s",
+			name, strings.Join(WeightsNames(), ", "))
+	}
+}
+
+// ParseWeights parses weights written as a comma-separated list of name=weight pairs, e.g. "holes=-0.4,lines=0.8".
+// ParseWeights returns error if the list is malformed. The feature names are not checked, see NewEvaluator.
+func ParseWeights(s string) (Weights, error) {
+	weights := Weights{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("ParseWeights: expected name=weight, got %q", pair)
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("ParseWeights: invalid weight of feature %q: %s", parts[0], err)
+		}
+		weights[strings.TrimSpace(parts[0])] = weight
+	}
+
+	return weights, nil
+}
+
+// LoadWeights reads weights from the JSON file with the given path.
+// The file contains an object whose keys are feature names and values are their weights,
+// e.g. {"holes": -0.4, "lines": 0.8}.
+// LoadWeights returns error if the file can not be read or is malformed. The feature names are not checked, see NewEvaluator.
+func LoadWeights(path string) (Weights, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadWeights: could not read weights: %s", err)
+	}
+
+	var weights Weights
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("LoadWeights: could not parse weights in %s: %s", path, err)
+	}
+
+	return weights, nil
+}
+
+// SaveWeights writes the given weights to the JSON file with the given path, in the format read by LoadWeights.
+// SaveWeights returns error if the file can not be written.
+func SaveWeights(path string, weights Weights) error {
+	data, err := json.MarshalIndent(weights, "", "\t")
+	if err != nil {
+		return fmt.Errorf("SaveWeights: could not encode weights: %s", err)
+	}
+
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("SaveWeights: could not write weights: %s", err)
+	}
+
+	return nil
+}
+
+// Evaluator is a utility function that evaluates boards with a weighted sum of features.
+// An Evaluator is immutable, so it may be shared by several AIs.
+// The zero value of Evaluator is not usable, function NewEvaluator should be used to create one.
+type Evaluator struct {
+	// names, features and weights contain the weighted features, sorted by name,
+	// so that they are always summed in the same order.
+	names    []string
+	features []Feature
+	weights  []float64
+}
+
+// defaultEvaluator is the evaluator of the AIs created with New and NewWithSize.
+var defaultEvaluator, _ = NewEvaluator(DefaultWeights())
+
+// NewEvaluator returns an evaluator that takes into account the features with the given weights.
+// NewEvaluator returns error if a feature name is unknown or a weight is not a finite number.
+func NewEvaluator(weights Weights) (*Evaluator, error) {
+	e := &Evaluator{}
+
+	for name := range weights {
+		e.names = append(e.names, name)
+	}
+	sort.Strings(e.names)
+
+	for _, name := range e.names {
+		feature, ok := features[name]
+		if !ok {
+			return nil, fmt.Errorf("NewEvaluator: unknown feature %q, expected one of: %s",
+				name, strings.Join(FeatureNames(), ", "))
+		}
+
+		weight := weights[name]
+		if math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("NewEvaluator: invalid weight %f of feature %q", weight, name)
+		}
+
+		e.features = append(e.features, feature)
+		e.weights = append(e.weights, weight)
+	}
+
+	return e, nil
+}
+
+// Weights returns a copy of the weights of the evaluator's features.
+func (e *Evaluator) Weights() Weights {
+	weights := make(Weights, len(e.names))
+	for i, name := range e.names {
+		weights[name] = e.weights[i]
+	}
+	return weights
+}
+
+// Evaluate returns a heuristical evaluation of the given board in the range [minUtility; maxUtility].
+// Greater utility means more desirable board for the AI. Boards whose game is over have the minimum utility.
+// A weighted sum of the features that is out of range, which large weights may lead to, is clamped to it,
+// so that every board whose game is not over is still evaluated better than the ones whose game is.
+func (e *Evaluator) Evaluate(board *tetris.Board) float64 {
+	if board.GameOver() {
+		return minUtility
+	}
+
+	var utility float64
+	for i, feature := range e.features {
+		utility += e.weights[i] * feature(board)
+	}
+
+	return math.Max(math.Nextafter(minUtility, maxUtility), math.Min(maxUtility, utility))
+}
+
+// Contribution is the part of a board's evaluation that comes from one weighted feature.
+type Contribution struct {
+	Feature string
+	Value   float64
+	Weight  float64
+}
+
+// Utility returns the contribution to the evaluation: the feature's value multiplied by its weight.
+func (c Contribution) Utility() float64 {
+	return c.Weight * c.Value
+}
+
+// Breakdown returns the contributions of the evaluator's features to the evaluation of the given board,
+// sorted by feature name. Unless the board's game is over or the sum is out of range,
+// their utilities add up to Evaluate's result.
+func (e *Evaluator) Breakdown(board *tetris.Board) []Contribution {
+	contributions := make([]Contribution, len(e.features))
+	for i, feature := range e.features {
+		contributions[i] = Contribution{Feature: e.names[i], Value: feature(board), Weight: e.weights[i]}
+	}
+	return contributions
+}
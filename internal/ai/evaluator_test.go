@@ -0,0 +1,235 @@
+package ai_test
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+func TestEvaluatorEvaluatesWeightedFeatures(t *testing.T) {
+	// The board has columns of heights 4, 4, 3 and 4 with 3 holes under them, and one line is cleared.
+	board := tetris.NewBoardWithSize(4, 20)
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 18, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoI, Row: 16, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoI, Orientation: tetris.OrientationRight, Row: 16, Col: 1})
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoZ, Row: 16, Col: 0})
+
+	tests := []struct {
+		weights ai.Weights
+		eval    float64
+	}{
+		{weights: ai.Weights{}, eval: 0},
+		{weights: ai.Weights{"height": 1}, eval: 15},
+		{weights: ai.Weights{"holes": -2}, eval: -6},
+		{weights: ai.Weights{"bumpiness": 0.5, "lines": 3}, eval: 0.5*2 + 3*1},
+	}
+
+	for _, test := range tests {
+		evaluator, err := ai.NewEvaluator(test.weights)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %s", test.weights, err)
+		}
+
+		if eval := evaluator.Evaluate(board); eval != test.eval {
+			t.Errorf("%v: expected evaluation %f, got %f", test.weights, test.eval, eval)
+		}
+		if !reflect.DeepEqual(evaluator.Weights(), test.weights) {
+			t.Errorf("expected weights %v, got %v", test.weights, evaluator.Weights())
+		}
+	}
+}
+
+func TestEvaluatorBreakdown(t *testing.T) {
+	// The board has columns of heights 4, 4, 3 and 4 with 3 holes under them, and one line is cleared,
+	// like in TestEvaluatorEvaluatesWeightedFeatures.
+	board := tetris.NewBoardWithSize(4, 20)
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 18, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoI, Row: 16, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoI, Orientation: tetris.OrientationRight, Row: 16, Col: 1})
+	board.Lock(tetris.Piece{Tetromino: tetris.TetrominoZ, Row: 16, Col: 0})
+
+	evaluator, err := ai.NewEvaluator(ai.Weights{"lines": 3, "holes": -2, "height": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []ai.Contribution{
+		{Feature: "height", Value: 15, Weight: 1},
+		{Feature: "holes", Value: 3, Weight: -2},
+		{Feature: "lines", Value: 1, Weight: 3},
+	}
+	breakdown := evaluator.Breakdown(board)
+	if !reflect.DeepEqual(breakdown, expected) {
+		t.Errorf("expected breakdown %v, got %v", expected, breakdown)
+	}
+
+	var sum float64
+	for _, contribution := range breakdown {
+		sum += contribution.Utility()
+	}
+	if sum != evaluator.Evaluate(board) {
+		t.Errorf("expected the contributions to add up to %f, got %f", evaluator.Evaluate(board), sum)
+	}
+}
+
+func TestNewEvaluatorRejectsUnknownFeatures(t *testing.T) {
+	if _, err := ai.NewEvaluator(ai.Weights{"height": -1, "unknown": 1}); err == nil {
+		t.Errorf("expected error for unknown feature")
+	}
+}
+
+func TestEvaluatorClampsExtremeWeights(t *testing.T) {
+	// The empty board has a row transition at each side of every row.
+	board := tetris.NewBoard()
+	lost := tetris.NewBoard()
+	lost.Lock(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 0, Col: 4})
+	if _, err := lost.Spawn(tetris.TetrominoO); err == nil {
+		t.Fatalf("expected the spawn to be blocked")
+	}
+
+	for _, weight := range []float64{-5000, 5000, -1e300, 1e300} {
+		evaluator, err := ai.NewEvaluator(ai.Weights{"row-transitions": weight})
+		if err != nil {
+			t.Fatalf("%f: unexpected error: %s", weight, err)
+		}
+
+		eval := evaluator.Evaluate(board)
+		if eval <= ai.MinUtility || eval > ai.MaxUtility {
+			t.Errorf("%f: expected evaluation in (%f; %f], got %f", weight, ai.MinUtility, ai.MaxUtility, eval)
+		}
+		if lostEval := evaluator.Evaluate(lost); lostEval >= eval {
+			t.Errorf("%f: expected the lost board to be evaluated worse than %f, got %f", weight, eval, lostEval)
+		}
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := ai.ParseWeights("holes=-0.4, lines=0.8,")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := (ai.Weights{"holes": -0.4, "lines": 0.8}); !reflect.DeepEqual(weights, expected) {
+		t.Errorf("expected weights %v, got %v", expected, weights)
+	}
+
+	for _, s := range []string{"holes", "holes=many"} {
+		if _, err := ai.ParseWeights(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	// The shipped default profile has the default weights.
+	weights, err := ai.LoadWeights(filepath.Join("..", "..", "weights", "default.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(weights, ai.DefaultWeights()) {
+		t.Errorf("expected default weights %v, got %v", ai.DefaultWeights(), weights)
+	}
+
+	dir, err := ioutil.TempDir("", "weights")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "malformed.json")
+	if err := ioutil.WriteFile(path, []byte(`{"holes": "many"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ai.LoadWeights(path); err == nil {
+		t.Errorf("expected error for malformed weights")
+	}
+
+	if _, err := ai.LoadWeights(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+
+	// Saved weights are loaded unchanged.
+	path = filepath.Join(dir, "saved.json")
+	saved := ai.Weights{"holes": -0.123456789, "lines": 1}
+	if err := ai.SaveWeights(path, saved); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if loaded, err := ai.LoadWeights(path); err != nil || !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("expected weights %v, got %v (error %v)", saved, loaded, err)
+	}
+}
+
+func TestAIUsesEvaluator(t *testing.T) {
+	// An AI that prefers holes plays very differently from the default one.
+	evaluator, err := ai.NewEvaluator(ai.Weights{"holes": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	holey := ai.NewWithSize(6, 20)
+	holey.SetEvaluator(evaluator)
+	holey.SetSeed(1)
+	holey.SetNext(tetris.TetrominoS)
+
+	for i := 0; i < 5; i++ {
+		if err := holey.DropSetNext(tetris.TetrominoS); err != nil {
+			break
+		}
+	}
+
+	var holes int
+	for _, h := range holey.Board().HolesByColumn() {
+		holes += h
+	}
+	if holes == 0 {
+		t.Errorf("expected the AI to leave holes")
+	}
+	if holey.Evaluator() != evaluator {
+		t.Errorf("expected the set evaluator to be used")
+	}
+}
+
+func TestNamedWeights(t *testing.T) {
+	for _, name := range ai.WeightsNames() {
+		weights, err := ai.NamedWeights(name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if _, err := ai.NewEvaluator(weights); err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		}
+	}
+
+	if _, err := ai.NamedWeights("unknown"); err == nil {
+		t.Errorf("expected error for unknown weights")
+	}
+}
+
+func TestAIWithElTetrisWeightsClearsLines(t *testing.T) {
+	weights, _ := ai.NamedWeights(ai.WeightsElTetris)
+	evaluator, err := ai.NewEvaluator(weights)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ai := ai.New()
+	ai.SetEvaluator(evaluator)
+	ai.SetSeed(1)
+
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	ai.SetNext(randomizer.Next())
+	for i := 0; i < 100; i++ {
+		if err := ai.DropSetNext(randomizer.Next()); err != nil {
+			t.Fatalf("unexpected game over after %d tetrominoes: %s", i, err)
+		}
+	}
+
+	if lines := ai.Board().ClearedLines(); lines < 30 {
+		t.Errorf("expected at least 30 cleared lines, got %d", lines)
+	}
+}
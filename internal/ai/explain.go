@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Candidate is a move that the AI considered, with the reasons for its evaluation, see Explain.
+type Candidate struct {
+	Move player.Move
+
+	// Eval is the evaluation of the move with which it is ranked, which also takes into account the tetrominoes
+	// after the current one. A move after which the next tetromino can not be placed is evaluated as a lost game.
+	Eval float64
+
+	// Board is the board after the move and Contributions are the contributions of the evaluator's features
+	// to its evaluation, see Evaluator.Breakdown.
+	Board         *tetris.Board
+	Contributions []Contribution
+}
+
+// Explain returns every move that the AI considers for the current tetromino on the board, knowing the queue after it,
+// ranked by their evaluation: the best move first, and equally good moves in the order in which they are considered.
+// The moves are evaluated like in Play, with the AI's search and depth, but without pruning, so that every evaluation
+// is exact. The time budget and beam search are not used. Explain does not change the board or the AI's seed.
+// Explain returns error if the current tetromino can not be placed anywhere.
+func (ai *AI) Explain(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) ([]Candidate, error) {
+	jobs := ai.jobs(board, current, queue)
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("AI.Explain: can not place tetromino %s", current)
+	}
+
+	probabilities := ai.probabilities()
+	evals, valid := ai.evaluateJobs(board, current, jobs, ai.depth, &probabilities, false)
+
+	candidates := make([]Candidate, len(jobs))
+	for i, job := range jobs {
+		after := tetris.NewBoardFromBoard(board)
+		if job.move.Hold {
+			after.Hold(current)
+		}
+		after.Lock(job.move.Placement.Piece)
+
+		candidates[i] = Candidate{
+			Move:          job.move,
+			Eval:          evals[i],
+			Board:         after,
+			Contributions: ai.evaluator.Breakdown(after),
+		}
+		if !valid[i] {
+			candidates[i].Eval = minUtility
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Eval > candidates[j].Eval
+	})
+
+	return candidates, nil
+}
+
+// Hint returns the move that the AI ranks best for the current tetromino on the board, knowing the queue after it,
+// to be suggested to a human player. It is the first move of Explain, so unlike Play, equally good moves are not
+// chosen at random and the same hint is given for the same game.
+// Hint returns error if the current tetromino can not be placed anywhere.
+func (ai *AI) Hint(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) (player.Move, error) {
+	candidates, err := ai.Explain(board, current, queue)
+	if err != nil {
+		return player.Move{}, fmt.Errorf("AI.Hint: %s", err)
+	}
+	return candidates[0].Move, nil
+}
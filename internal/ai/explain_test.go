@@ -0,0 +1,119 @@
+package ai_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+func TestAIExplainRanksEveryMove(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+
+	for i := 0; i < 10; i++ {
+		board := randomBoard(rng, 6, 12)
+		current := tetris.Tetrominoes()[rng.Intn(tetris.TetrominoesCount)]
+		queue := []tetris.Tetromino{tetris.Tetrominoes()[rng.Intn(tetris.TetrominoesCount)]}
+		if len(board.Placements(current)) == 0 {
+			continue
+		}
+
+		a := ai.NewWithSize(6, 12)
+		a.SetSeed(int64(i))
+		candidates, err := a.Explain(board, current, queue)
+		if err != nil {
+			t.Fatalf("board %d: unexpected error: %s", i, err)
+		}
+
+		if len(candidates) != len(board.Placements(current)) {
+			t.Errorf("board %d: expected %d candidates, got %d", i, len(board.Placements(current)), len(candidates))
+		}
+		for j := 1; j < len(candidates); j++ {
+			if candidates[j-1].Eval < candidates[j].Eval {
+				t.Errorf("board %d: candidates %d and %d are not ranked by evaluation", i, j-1, j)
+			}
+		}
+
+		// Play chooses one of the moves that Explain ranks best, unless they all lose the game.
+		move, err := a.Play(board, current, queue)
+		if err != nil {
+			if candidates[0].Eval != ai.MinUtility {
+				t.Errorf("board %d: unexpected error: %s", i, err)
+			}
+			continue
+		}
+		best := false
+		for _, candidate := range candidates {
+			if candidate.Eval == candidates[0].Eval && candidate.Move.Placement.Piece == move.Placement.Piece {
+				best = true
+			}
+		}
+		if !best {
+			t.Errorf("board %d: expected move %v to be one of the best candidates", i, move.Placement.Piece)
+		}
+	}
+}
+
+func TestAIExplainBreaksDownEvaluation(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Drop(tetris.TetrominoI, 0, 0)
+	board.Drop(tetris.TetrominoT, 0, 4)
+	board.Drop(tetris.TetrominoO, 0, 8)
+
+	// Without the queue and unknown tetrominoes, the evaluation of a move is the one of the board after it.
+	a := ai.New()
+	a.SetDepth(0)
+	candidates, err := a.Explain(board, tetris.TetrominoS, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i, candidate := range candidates {
+		cell := candidate.Move.Placement.Piece.Cells()[0]
+		if candidate.Board.DroppedTetrominoes() != 4 || candidate.Board.At(cell.Row, cell.Col) != tetris.TetrominoS {
+			t.Errorf("candidate %d: expected the board after placing the S", i)
+		}
+
+		var sum float64
+		for _, contribution := range candidate.Contributions {
+			sum += contribution.Utility()
+		}
+		if candidate.Eval != a.Evaluator().Evaluate(candidate.Board) || sum != candidate.Eval {
+			t.Errorf("candidate %d: expected evaluation %f, got %f with contributions adding up to %f",
+				i, a.Evaluator().Evaluate(candidate.Board), candidate.Eval, sum)
+		}
+	}
+}
+
+func TestAIHintIsBestExplainedMove(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Drop(tetris.TetrominoI, 0, 0)
+	board.Drop(tetris.TetrominoI, 0, 4)
+
+	a := ai.New()
+	candidates, err := a.Explain(board, tetris.TetrominoO, []tetris.Tetromino{tetris.TetrominoL})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The hint does not depend on the seed, unlike the moves that Play chooses between equally good ones.
+	for seed := int64(0); seed < 3; seed++ {
+		a.SetSeed(seed)
+		hint, err := a.Hint(board, tetris.TetrominoO, []tetris.Tetromino{tetris.TetrominoL})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !hint.Matches(candidates[0].Move) {
+			t.Errorf("seed %d: expected hint %v, got %v", seed, candidates[0].Move.Placement.Piece, hint.Placement.Piece)
+		}
+	}
+
+	full := tetris.NewBoardWithSize(4, 4)
+	for !full.GameOver() {
+		full.Drop(tetris.TetrominoO, 0, 0)
+	}
+	if _, err := ai.NewWithSize(4, 4).Hint(full, tetris.TetrominoO, nil); err == nil {
+		t.Errorf("expected error when the tetromino can not be placed")
+	}
+}
@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"math"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Bounds of the evaluation function, for tests.
+const (
+	MinUtility = minUtility
+	MaxUtility = maxUtility
+)
+
+// Evaluate exposes the pruned minimax search for tests.
+func (ai *AI) Evaluate(board *tetris.Board, depth int, alpha, beta float64) float64 {
+	return ai.evaluate(board, depth, alpha, beta)
+}
+
+// ReferenceEvaluate is the minimax search of evaluate without pruning, against which evaluate is tested.
+func (ai *AI) ReferenceEvaluate(board *tetris.Board, depth int) float64 {
+	if depth == 0 || board.GameOver() {
+		return ai.evaluator.Evaluate(board)
+	}
+
+	minEval := math.Inf(1)
+	for _, tetromino := range tetris.Tetrominoes() {
+		maxEval := math.Inf(-1)
+		for rotation := 0; rotation < tetromino.RotationsCount(); rotation++ {
+			for column := 0; column <= board.Width()-len(ai.matrices[tetromino][rotation][0]); column++ {
+				newBoard := tetris.NewShapeFromBoard(board)
+
+				eval := minUtility
+				if err := newBoard.Drop(tetromino, rotation, column); err == nil {
+					eval = ai.ReferenceEvaluate(newBoard, depth-1)
+				}
+				maxEval = math.Max(maxEval, eval)
+			}
+		}
+		minEval = math.Min(minEval, maxEval)
+	}
+	return minEval
+}
@@ -0,0 +1,439 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// MCTSName is the name with which the Monte Carlo tree search player is registered, see NewMCTS.
+const MCTSName = "mcts"
+
+func init() {
+	player.Register(MCTSName, func(seed int64) player.Player {
+		m := NewMCTS()
+		m.SetSeed(seed)
+		return m
+	})
+}
+
+// Rollout is the policy with which MCTS plays the tetrominoes after the ones in its tree.
+type Rollout int
+
+const (
+	// RolloutGreedy drops each tetromino where the evaluator evaluates the board best.
+	RolloutGreedy Rollout = iota
+
+	// RolloutRandom drops each tetromino at a random rotation and column.
+	RolloutRandom
+)
+
+// Defaults of MCTS, see the setters.
+const (
+	DefaultIterations   = 1000
+	DefaultRolloutDepth = 3
+	DefaultExploration  = 0.5
+)
+
+// mctsIterations, mctsRolloutDepth and mctsExploration are the options of the MCTS players created with NewMCTS.
+var (
+	mctsIterations   = DefaultIterations
+	mctsRolloutDepth = DefaultRolloutDepth
+	mctsExploration  = DefaultExploration
+)
+
+// SetDefaultMCTS sets the number of iterations, the rollout depth and the exploration constant of the MCTS players
+// created afterwards, including the registered ones, see MCTS.SetIterations, MCTS.SetRollout and MCTS.SetExploration.
+// By default, they are DefaultIterations, DefaultRolloutDepth and DefaultExploration.
+// SetDefaultMCTS panics if any of them is negative.
+func SetDefaultMCTS(iterations, rolloutDepth int, exploration float64) {
+	if iterations < 0 || rolloutDepth < 0 || exploration < 0 || math.IsNaN(exploration) {
+		panic(fmt.Errorf("SetDefaultMCTS: invalid iterations %d, rollout depth %d or exploration %f provided",
+			iterations, rolloutDepth, exploration))
+	}
+	mctsIterations, mctsRolloutDepth, mctsExploration = iterations, rolloutDepth, exploration
+}
+
+// MCTS is a player that chooses moves with Monte Carlo tree search, https://en.wikipedia.org/wiki/Monte_Carlo_tree_search.
+// Each iteration of the search selects placements in the tree of the known and sampled tetrominoes with UCT,
+// adds a placement to the tree and plays a rollout after it: a few more tetrominoes that are dropped straight down.
+// The board after the rollout is evaluated with an evaluator, see Evaluator, and a rollout that ends the game is a loss.
+// The placement of the current tetromino that was searched most is played.
+// The tetrominoes after the known ones are sampled with the probabilities of the randomizer that deals them,
+// see SetRandomizer, assuming that each of them is as likely as the first unknown one.
+// After each move, the searched subtree of the resulting board is reused by the next move.
+// MCTS never holds. The zero value of MCTS is not usable, NewMCTS should be used to create one.
+type MCTS struct {
+	evaluator  *Evaluator
+	randomizer tetris.Randomizer
+
+	// iterations and timeBudget bound the search of each move. rolloutDepth is the number of tetrominoes
+	// dropped in each rollout and exploration is the UCT constant that weights the less searched placements.
+	iterations   int
+	timeBudget   time.Duration
+	rolloutDepth int
+	rollout      Rollout
+	exploration  float64
+
+	// tree is the search tree of the last move and chosen is the root's edge that was played,
+	// whose subtree is reused by the next move if its board is the one after the move.
+	tree   *mctsTree
+	chosen *mctsEdge
+
+	rng *rand.Rand
+}
+
+// mctsTree is a search tree, whose evaluations are normalized by the range of the evaluations of its rollouts.
+type mctsTree struct {
+	root     *mctsNode
+	min, max float64
+}
+
+// mctsNode is a board in the search tree, on which a tetromino is placed.
+type mctsNode struct {
+	board     *tetris.Board
+	tetromino tetris.Tetromino
+	visits    int
+
+	// edges are the placements of the tetromino, which are listed when the node is first searched.
+	edges    []*mctsEdge
+	expanded bool
+}
+
+// mctsEdge is a placement in the search tree and the results of the rollouts after it.
+type mctsEdge struct {
+	placement tetris.Placement
+	board     *tetris.Board
+
+	// visits is the number of rollouts after the placement, losses is the number of them that ended the game
+	// and sum is the sum of the evaluations of the others.
+	visits int
+	losses int
+	sum    float64
+
+	// next contains the nodes after the placement by the tetromino that comes next.
+	next map[tetris.Tetromino]*mctsNode
+}
+
+// NewMCTS creates a Monte Carlo tree search player with the default evaluator, see SetDefaultEvaluator,
+// the default time budget, see SetDefaultTimeBudget, and the default options of the search, see SetDefaultMCTS.
+func NewMCTS() *MCTS {
+	return &MCTS{
+		evaluator:    defaultEvaluator,
+		iterations:   mctsIterations,
+		timeBudget:   defaultTimeBudget,
+		rolloutDepth: mctsRolloutDepth,
+		rollout:      RolloutGreedy,
+		exploration:  mctsExploration,
+		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetSeed seeds the source of random numbers with which the search samples tetrominoes and plays rollouts.
+// Without a time budget, MCTS with a given seed always makes the same moves for the same tetrominoes.
+func (m *MCTS) SetSeed(seed int64) {
+	m.rng = rand.New(rand.NewSource(seed))
+}
+
+// SetEvaluator sets the utility function with which the boards after the rollouts are evaluated.
+// SetEvaluator panics if the evaluator is nil.
+func (m *MCTS) SetEvaluator(evaluator *Evaluator) {
+	if evaluator == nil {
+		panic(fmt.Errorf("MCTS.SetEvaluator: nil evaluator provided"))
+	}
+	m.evaluator = evaluator
+	m.tree = nil
+}
+
+// SetRandomizer implements player.RandomizerObserver: the tetrominoes after the known ones are sampled
+// with the probabilities of the randomizer, see tetris.NextProbabilities.
+func (m *MCTS) SetRandomizer(randomizer tetris.Randomizer) {
+	m.randomizer = randomizer
+}
+
+// SetIterations sets the maximum number of iterations of the search of each move, see SetDefaultMCTS.
+// 0 means that the number is only limited by the time budget, see SetTimeBudget, or to one without it.
+// SetIterations panics if the number is negative.
+func (m *MCTS) SetIterations(iterations int) {
+	if iterations < 0 {
+		panic(fmt.Errorf("MCTS.SetIterations: invalid number of iterations %d provided", iterations))
+	}
+	m.iterations = iterations
+}
+
+// SetTimeBudget sets the maximum time of the search of each move, which stops at whichever limit it reaches first,
+// see SetIterations. 0 means that the time is not limited. The search always makes at least one iteration.
+// SetTimeBudget panics if the budget is negative.
+func (m *MCTS) SetTimeBudget(budget time.Duration) {
+	if budget < 0 {
+		panic(fmt.Errorf("MCTS.SetTimeBudget: invalid time budget %s provided", budget))
+	}
+	m.timeBudget = budget
+}
+
+// SetRollout sets the policy and the number of tetrominoes of the rollouts,
+// RolloutGreedy and the default rollout depth by default, see SetDefaultMCTS.
+// SetRollout panics if the number of tetrominoes is negative.
+func (m *MCTS) SetRollout(rollout Rollout, depth int) {
+	if depth < 0 {
+		panic(fmt.Errorf("MCTS.SetRollout: invalid rollout depth %d provided", depth))
+	}
+	m.rollout, m.rolloutDepth = rollout, depth
+}
+
+// SetExploration sets the UCT constant with which the search weights the less searched placements against
+// the better evaluated ones, whose evaluations are normalized to [0; 1]. See SetDefaultMCTS for its default.
+// SetExploration panics if the constant is negative.
+func (m *MCTS) SetExploration(exploration float64) {
+	if exploration < 0 || math.IsNaN(exploration) {
+		panic(fmt.Errorf("MCTS.SetExploration: invalid exploration constant %f provided", exploration))
+	}
+	m.exploration = exploration
+}
+
+// Visits returns the number of iterations that searched the last move,
+// including the ones made by the previous move in the reused subtree.
+func (m *MCTS) Visits() int {
+	if m.tree == nil {
+		return 0
+	}
+	return m.tree.root.visits
+}
+
+// Play implements player.Player. Play searches the placements of the current tetromino and the queue after it,
+// see MCTS, and returns the most searched placement of the current tetromino.
+func (m *MCTS) Play(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) (player.Move, error) {
+	m.reuse(board, current)
+
+	var deadline time.Time
+	if m.timeBudget > 0 {
+		deadline = time.Now().Add(m.timeBudget)
+	}
+
+	probabilities := tetris.NextProbabilities(m.randomizer)
+	for i := 0; i == 0 || m.searching(i, deadline); i++ {
+		m.iterate(queue, &probabilities)
+	}
+
+	root := m.tree.root
+	if len(root.edges) == 0 {
+		return player.Move{}, fmt.Errorf("MCTS.Play: can not place tetromino %s", current)
+	}
+
+	// The most searched placement is the most reliable one. Ties are broken by the evaluation.
+	m.chosen = root.edges[0]
+	for _, edge := range root.edges[1:] {
+		if edge.visits > m.chosen.visits ||
+			edge.visits == m.chosen.visits && m.tree.value(edge) > m.tree.value(m.chosen) {
+			m.chosen = edge
+		}
+	}
+
+	return player.Move{Placement: m.chosen.placement}, nil
+}
+
+// searching returns whether the search continues after the given number of iterations, before the given deadline,
+// which is zero without a time budget. Without any limit, the search stops after the first iteration.
+func (m *MCTS) searching(iterations int, deadline time.Time) bool {
+	if m.iterations == 0 && deadline.IsZero() {
+		return false
+	}
+	return (m.iterations == 0 || iterations < m.iterations) && (deadline.IsZero() || time.Now().Before(deadline))
+}
+
+// reuse makes the root of the tree the node after the last move, if it is the given board with the current tetromino,
+// or a new node otherwise.
+func (m *MCTS) reuse(board *tetris.Board, current tetris.Tetromino) {
+	if m.tree != nil && m.chosen != nil {
+		if node := m.chosen.next[current]; node != nil && sameShape(node.board, board) {
+			m.tree.root = node
+			m.chosen = nil
+			return
+		}
+	}
+
+	m.tree = &mctsTree{
+		root: &mctsNode{board: tetris.NewShapeFromBoard(board), tetromino: current},
+		min:  math.Inf(1),
+		max:  math.Inf(-1),
+	}
+	m.chosen = nil
+}
+
+// sameShape returns whether the boards have the same shape and statistics that are evaluated.
+func sameShape(a, b *tetris.Board) bool {
+	return a.Width() == b.Width() && a.Height() == b.Height() &&
+		a.Hash() == b.Hash() && a.ClearedLines() == b.ClearedLines() && a.GameOver() == b.GameOver()
+}
+
+// iterate makes one iteration of the search: it selects placements from the root until it reaches one
+// that has not been searched, plays a rollout after it and updates the placements with its result.
+// If it reaches a tetromino that can not be placed, the game is lost instead.
+func (m *MCTS) iterate(queue []tetris.Tetromino, probabilities *[tetris.TetrominoesCount + 1]float64) {
+	var (
+		path  []*mctsEdge
+		node  = m.tree.root
+		known = queue
+	)
+
+	lost, eval := true, 0.0
+	for {
+		node.visits++
+		if !node.expanded {
+			for _, placement := range node.board.Placements(node.tetromino) {
+				node.edges = append(node.edges, &mctsEdge{placement: placement})
+			}
+			node.expanded = true
+		}
+		if len(node.edges) == 0 {
+			// The tetromino can not be placed, so the game is over.
+			break
+		}
+
+		edge := m.tree.selectEdge(node, m.exploration)
+		path = append(path, edge)
+
+		next := m.sample(probabilities)
+		if len(known) > 0 {
+			next, known = known[0], known[1:]
+		}
+
+		if edge.board == nil {
+			edge.board = tetris.NewShapeFromBoard(node.board)
+			edge.board.Lock(edge.placement.Piece)
+			lost, eval = m.playRollout(edge.board, next, probabilities)
+			break
+		}
+		child := edge.next[next]
+		if child == nil {
+			if edge.next == nil {
+				edge.next = make(map[tetris.Tetromino]*mctsNode)
+			}
+			child = &mctsNode{board: edge.board, tetromino: next}
+			edge.next[next] = child
+		}
+		node = child
+	}
+
+	if !lost {
+		m.tree.min = math.Min(m.tree.min, eval)
+		m.tree.max = math.Max(m.tree.max, eval)
+	}
+	for _, edge := range path {
+		edge.visits++
+		if lost {
+			edge.losses++
+		} else {
+			edge.sum += eval
+		}
+	}
+}
+
+// sample returns a random tetromino with the given probabilities.
+func (m *MCTS) sample(probabilities *[tetris.TetrominoesCount + 1]float64) tetris.Tetromino {
+	r := m.rng.Float64()
+	for _, tetromino := range tetris.Tetrominoes() {
+		r -= probabilities[tetromino]
+		if r < 0 {
+			return tetromino
+		}
+	}
+
+	// The probabilities may not add up to exactly 1.
+	tetrominoes := tetris.Tetrominoes()
+	return tetrominoes[len(tetrominoes)-1]
+}
+
+// playRollout drops the given tetromino and the sampled ones after it on a copy of the board, see SetRollout,
+// and returns whether the game is lost or the evaluation of the board after the rollout.
+func (m *MCTS) playRollout(board *tetris.Board, tetromino tetris.Tetromino,
+	probabilities *[tetris.TetrominoesCount + 1]float64) (bool, float64) {
+	if board.GameOver() {
+		return true, 0
+	}
+
+	var (
+		matrices = tetris.TetrominoMatrices()
+		current  = tetris.NewShapeFromBoard(board)
+		best     = tetris.NewShapeFromBoard(board)
+		next     = tetris.NewShapeFromBoard(board)
+	)
+	for i := 0; i < m.rolloutDepth; i++ {
+		if i > 0 {
+			tetromino = m.sample(probabilities)
+		}
+
+		var drops [][2]int
+		for rotation := 0; rotation < tetromino.RotationsCount(); rotation++ {
+			for column := 0; column <= board.Width()-len(matrices[tetromino][rotation][0]); column++ {
+				drops = append(drops, [2]int{rotation, column})
+			}
+		}
+
+		if m.rollout == RolloutRandom {
+			drop := drops[m.rng.Intn(len(drops))]
+			if err := current.Drop(tetromino, drop[0], drop[1]); err != nil {
+				return true, 0
+			}
+			continue
+		}
+
+		bestEval, found := 0.0, false
+		for _, drop := range drops {
+			next.CopyFrom(current)
+			if err := next.Drop(tetromino, drop[0], drop[1]); err != nil {
+				continue
+			}
+
+			if eval := m.evaluator.Evaluate(next); !found || eval > bestEval {
+				bestEval, found = eval, true
+				best.CopyFrom(next)
+			}
+		}
+		if !found {
+			return true, 0
+		}
+		current.CopyFrom(best)
+	}
+
+	return false, m.evaluator.Evaluate(current)
+}
+
+// value returns the normalized evaluation of the placement in [0; 1]: the average evaluation of its rollouts,
+// normalized by the range of the evaluations in the tree, and a loss counted as 0.
+func (t *mctsTree) value(edge *mctsEdge) float64 {
+	if edge.visits == 0 || edge.losses == edge.visits {
+		return 0
+	}
+
+	normalized := 0.5
+	if t.max > t.min {
+		normalized = (edge.sum/float64(edge.visits-edge.losses) - t.min) / (t.max - t.min)
+	}
+	return normalized * float64(edge.visits-edge.losses) / float64(edge.visits)
+}
+
+// selectEdge returns the first placement of the node that has not been searched, if any,
+// or the one with the greatest upper confidence bound, see https://en.wikipedia.org/wiki/Monte_Carlo_tree_search#Exploration_and_exploitation.
+func (t *mctsTree) selectEdge(node *mctsNode, exploration float64) *mctsEdge {
+	var (
+		best      *mctsEdge
+		bestBound = math.Inf(-1)
+		logVisits = math.Log(float64(node.visits))
+	)
+	for _, edge := range node.edges {
+		if edge.visits == 0 {
+			return edge
+		}
+
+		bound := t.value(edge) + exploration*math.Sqrt(logVisits/float64(edge.visits))
+		if bound > bestBound {
+			best, bestBound = edge, bound
+		}
+	}
+	return best
+}
@@ -0,0 +1,101 @@
+package ai_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// playMCTS plays the given number of tetrominoes, dealt by a 7-bag randomizer, with the given player
+// and returns the game. playMCTS fails the test if the game is over.
+func playMCTS(t *testing.T, p player.Player, tetrominoes int) *player.Game {
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	game := player.NewGame(tetris.NewBoard(), p, randomizer, 1)
+	for i := 0; i < tetrominoes; i++ {
+		if err := game.Play(); err != nil {
+			t.Fatalf("unexpected game over after %d tetrominoes: %s", i, err)
+		}
+	}
+	return game
+}
+
+func TestMCTSPlaysGameAsPlayer(t *testing.T) {
+	p, err := player.New(ai.MCTSName, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	game := playMCTS(t, p, 40)
+	if game.Board().ClearedLines() < 10 {
+		t.Errorf("expected at least 10 cleared lines, got %d", game.Board().ClearedLines())
+	}
+}
+
+func TestMCTSWithSeedIsDeterministic(t *testing.T) {
+	var boards []*tetris.Board
+	for i := 0; i < 2; i++ {
+		m := ai.NewMCTS()
+		m.SetSeed(1)
+		m.SetIterations(100)
+		m.SetRollout(ai.RolloutRandom, 5)
+		boards = append(boards, playMCTS(t, m, 20).Board())
+	}
+
+	for row := 0; row < boards[0].Height(); row++ {
+		for col := 0; col < boards[0].Width(); col++ {
+			if boards[0].At(row, col) != boards[1].At(row, col) {
+				t.Fatalf("expected the same boards, cell (%d, %d) differs", row, col)
+			}
+		}
+	}
+}
+
+func TestMCTSReusesSubtree(t *testing.T) {
+	m := ai.NewMCTS()
+	m.SetSeed(1)
+	m.SetIterations(200)
+
+	board := tetris.NewBoard()
+	move, err := m.Play(board, tetris.TetrominoT, []tetris.Tetromino{tetris.TetrominoL})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m.Visits() != 200 {
+		t.Errorf("expected 200 visits, got %d", m.Visits())
+	}
+
+	// The most searched placement has been searched further after the L, which is known to come next.
+	board.Lock(move.Placement.Piece)
+	if _, err := m.Play(board, tetris.TetrominoL, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m.Visits() <= 200 {
+		t.Errorf("expected more than 200 visits of the reused subtree, got %d", m.Visits())
+	}
+
+	// A board that is not the one after the move is searched from scratch.
+	if _, err := m.Play(tetris.NewBoard(), tetris.TetrominoO, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m.Visits() != 200 {
+		t.Errorf("expected 200 visits of a new tree, got %d", m.Visits())
+	}
+}
+
+func TestMCTSWithTimeBudget(t *testing.T) {
+	m := ai.NewMCTS()
+	m.SetIterations(0)
+	m.SetTimeBudget(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := m.Play(tetris.NewBoard(), tetris.TetrominoI, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || m.Visits() == 0 {
+		t.Errorf("expected a search of about 20ms, got %d iterations in %s", m.Visits(), elapsed)
+	}
+}
@@ -0,0 +1,252 @@
+package ai_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// randomBoard returns a board of the given size on which random placements of random tetrominoes have been locked.
+// The board's game may be over.
+func randomBoard(rng *rand.Rand, width, height int) *tetris.Board {
+	board := tetris.NewBoardWithSize(width, height)
+	for i := rng.Intn(3 * height / 2); i > 0 && !board.GameOver(); i-- {
+		placements := board.Placements(tetris.Tetrominoes()[rng.Intn(tetris.TetrominoesCount)])
+		if len(placements) == 0 {
+			break
+		}
+		board.Lock(placements[rng.Intn(len(placements))].Piece)
+	}
+	return board
+}
+
+// randomEvaluator returns an evaluator with random weights of random features.
+func randomEvaluator(rng *rand.Rand) *ai.Evaluator {
+	weights := ai.Weights{}
+	for _, name := range ai.FeatureNames() {
+		if rng.Intn(2) == 0 {
+			weights[name] = 2*rng.Float64() - 1
+		}
+	}
+
+	evaluator, _ := ai.NewEvaluator(weights)
+	return evaluator
+}
+
+func TestAIEvaluateEqualsReferenceMinimax(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 30; i++ {
+		a := ai.NewWithSize(5, 10)
+		a.SetEvaluator(randomEvaluator(rng))
+		board := randomBoard(rng, 5, 10)
+
+		for depth := 0; depth <= 2; depth++ {
+			reference := a.ReferenceEvaluate(board, depth)
+			if eval := a.Evaluate(board, depth, ai.MinUtility, ai.MaxUtility); eval != reference {
+				t.Errorf("board %d, depth %d: expected evaluation %f, got %f", i, depth, reference, eval)
+			}
+		}
+	}
+}
+
+func TestAIEvaluateRespectsAlphaBetaBounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 30; i++ {
+		a := ai.NewWithSize(5, 10)
+		a.SetEvaluator(randomEvaluator(rng))
+		board := randomBoard(rng, 5, 10)
+
+		for depth := 1; depth <= 2; depth++ {
+			reference := a.ReferenceEvaluate(board, depth)
+
+			// The window is around the evaluation, so that it is often inside, below or above the window.
+			alpha := reference + 2*rng.NormFloat64()
+			beta := alpha + 4*rng.Float64()
+			eval := a.Evaluate(board, depth, alpha, beta)
+
+			switch {
+			case reference <= alpha:
+				if eval < reference || eval > alpha {
+					t.Errorf("board %d, depth %d: evaluation %f is at most alpha %f, got %f", i, depth, reference, alpha, eval)
+				}
+			case reference >= beta:
+				if eval > reference || eval < beta {
+					t.Errorf("board %d, depth %d: evaluation %f is at least beta %f, got %f", i, depth, reference, beta, eval)
+				}
+			default:
+				if eval != reference {
+					t.Errorf("board %d, depth %d: expected evaluation %f in (%f, %f), got %f", i, depth, reference, alpha, beta, eval)
+				}
+			}
+		}
+	}
+}
+
+func TestAIPlaysWithGreaterDepth(t *testing.T) {
+	a := ai.NewWithSize(6, 12)
+	a.SetDepth(2)
+	a.SetSeed(1)
+
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	a.SetNext(randomizer.Next())
+	for i := 0; i < 10; i++ {
+		if err := a.DropSetNext(randomizer.Next()); err != nil {
+			t.Fatalf("unexpected game over: %s", err)
+		}
+	}
+
+	if a.Depth() != 2 || a.Board().DroppedTetrominoes() != 10 {
+		t.Errorf("expected depth 2 and 10 dropped tetrominoes, got %d and %d", a.Depth(), a.Board().DroppedTetrominoes())
+	}
+}
+
+func TestAIMovesDoNotDependOnWorkers(t *testing.T) {
+	for _, search := range []ai.Search{ai.SearchMinimax, ai.SearchExpectimax} {
+		var boards []*tetris.Board
+		for _, workers := range []int{1, 4} {
+			a := ai.New()
+			a.SetSeed(1)
+			a.SetSearch(search)
+			a.SetWorkers(workers)
+
+			randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+			game := player.NewGame(tetris.NewBoard(), a, randomizer, 1)
+			for i := 0; i < 20; i++ {
+				if err := game.Play(); err != nil {
+					t.Fatalf("unexpected game over: %s", err)
+				}
+			}
+			boards = append(boards, game.Board())
+		}
+
+		for _, board := range boards[1:] {
+			for row := 0; row < board.Height(); row++ {
+				for col := 0; col < board.Width(); col++ {
+					if board.At(row, col) != boards[0].At(row, col) {
+						t.Fatalf("search %d: expected the same boards, cell (%d, %d) differs", search, row, col)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAIEvaluateWithTranspositionTable(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	// The smallest table keeps replacing its entries, which must not change the evaluations either.
+	for _, size := range []int{0, 1, ai.DefaultTableSize} {
+		a := ai.NewWithSize(5, 10)
+		a.SetTableSize(size)
+
+		for i := 0; i < 10; i++ {
+			board := randomBoard(rng, 5, 10)
+
+			// The evaluations cached with one evaluator must not be used with another one.
+			for e := 0; e < 2; e++ {
+				a.SetEvaluator(randomEvaluator(rng))
+				reference := a.ReferenceEvaluate(board, 2)
+
+				// Evaluating the board again finds the evaluations cached the first time.
+				for j := 0; j < 2; j++ {
+					if eval := a.Evaluate(board, 2, ai.MinUtility, ai.MaxUtility); eval != reference {
+						t.Errorf("size %d, board %d, evaluator %d: expected evaluation %f, got %f", size, i, e, reference, eval)
+					}
+				}
+			}
+		}
+
+		stats := a.TableStats()
+		if size == 0 && stats != (ai.TableStats{}) {
+			t.Errorf("expected no lookups without a table, got %+v", stats)
+		}
+		if size == ai.DefaultTableSize && (stats.Hits == 0 || stats.Misses == 0) {
+			t.Errorf("expected both hits and misses, got %+v", stats)
+		}
+	}
+}
+
+func TestAIEvaluateBoardsOfDifferentSizesWithTable(t *testing.T) {
+	// The boards have the same rows from the top, so they have the same hash, but the columns of the taller one are higher.
+	short, tall := tetris.NewBoardWithSize(5, 10), tetris.NewBoardWithSize(5, 12)
+	for _, board := range []*tetris.Board{short, tall} {
+		board.Lock(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 8, Col: 0})
+	}
+
+	a := ai.NewWithSize(5, 10)
+	for _, board := range []*tetris.Board{short, tall} {
+		reference := a.ReferenceEvaluate(board, 1)
+		if eval := a.Evaluate(board, 1, ai.MinUtility, ai.MaxUtility); eval != reference {
+			t.Errorf("%dx%d board: expected evaluation %f, got %f", board.Width(), board.Height(), reference, eval)
+		}
+	}
+}
+
+func TestAITableStatsHitRate(t *testing.T) {
+	if rate := (ai.TableStats{}).HitRate(); rate != 0 {
+		t.Errorf("expected hit rate 0 without lookups, got %f", rate)
+	}
+	if rate := (ai.TableStats{Hits: 1, Misses: 3}).HitRate(); rate != 0.25 {
+		t.Errorf("expected hit rate 0.25, got %f", rate)
+	}
+}
+
+func TestAIPlayContextDeepensIteratively(t *testing.T) {
+	board := randomBoard(rand.New(rand.NewSource(4)), 10, 20)
+	queue := []tetris.Tetromino{tetris.TetrominoT}
+
+	// play returns the piece placed by a new AI with the given depth and the depth with which it was chosen.
+	play := func(ctx context.Context, depth int) (tetris.Piece, int) {
+		a := ai.New()
+		a.SetSeed(1)
+		a.SetDepth(depth)
+		move, err := a.PlayContext(ctx, board, tetris.TetrominoL, queue)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return move.Placement.Piece, a.CompletedDepth()
+	}
+
+	// The last completed search chooses the same move as a search with only its depth.
+	expected, _ := play(context.Background(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	if piece, depth := play(ctx, 1); piece != expected || depth != 1 {
+		t.Errorf("expected piece %v with depth 1, got %v with depth %d", expected, piece, depth)
+	}
+
+	// The search with depth 0 is completed even if the context is already done.
+	expected, _ = play(context.Background(), 0)
+	cancel()
+	if piece, depth := play(ctx, 5); piece != expected || depth != 0 {
+		t.Errorf("expected piece %v with depth 0, got %v with depth %d", expected, piece, depth)
+	}
+}
+
+func TestAIPlaysWithinTimeBudget(t *testing.T) {
+	a := ai.New()
+	a.SetSeed(1)
+	a.SetDepth(10)
+	a.SetTimeBudget(50 * time.Millisecond)
+
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	game := player.NewGame(tetris.NewBoard(), a, randomizer, 1)
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if err := game.Play(); err != nil {
+			t.Fatalf("unexpected game over: %s", err)
+		}
+
+		// The search with depth 10 would take days, so it must have been cancelled soon after the budget ran out.
+		if elapsed := time.Since(start); elapsed > time.Second || a.CompletedDepth() >= 10 {
+			t.Errorf("move %d: expected a cancelled search, got depth %d in %s", i, a.CompletedDepth(), elapsed)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// DefaultTableSize is the number of entries of the AI's transposition table by default, see AI.SetTableSize.
+const DefaultTableSize = 1 << 16
+
+// tableLocks is the number of locks that guard the entries of a transposition table.
+// Each lock guards every tableLocks-th entry, so that workers rarely wait for each other.
+const tableLocks = 64
+
+// tableBound tells how the value of a table entry relates to the evaluation it was stored for, see AI.evaluate.
+type tableBound uint8
+
+const (
+	boundExact tableBound = iota + 1
+	boundLower
+	boundUpper
+)
+
+// entry is an evaluation stored in a transposition table.
+// The zero value of entry is an empty entry.
+type entry struct {
+	key   uint64
+	value float64
+	bound tableBound
+}
+
+// TableStats are the statistics of a transposition table's lookups, useful for benchmarking.
+type TableStats struct {
+	// Hits is the number of lookups that found a usable evaluation and Misses is the number of the others.
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns the fraction of lookups that were hits, or 0 if there were none.
+func (s TableStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// table is a bounded transposition table: a cache of evaluations of the best drop of a tetromino on a board.
+// The same boards are reached in many ways, e.g. by dropping two tetrominoes in either order,
+// so the table saves evaluating them again.
+// An evaluation is keyed by everything it depends on: the board's shape and cleared lines, the tetromino and the depth.
+// When two keys fall in the same entry, the newer evaluation replaces the older one.
+// A table is safe for concurrent use.
+type table struct {
+	entries []entry
+	mask    uint64
+	locks   [tableLocks]sync.Mutex
+
+	hits   uint64
+	misses uint64
+}
+
+// newTable returns an empty table with at least the given number of entries, rounded up to a power of two.
+func newTable(size int) *table {
+	entries := tableLocks
+	for entries < size {
+		entries *= 2
+	}
+	return &table{
+		entries: make([]entry, entries),
+		mask:    uint64(entries - 1),
+	}
+}
+
+// tableKey returns the key of the evaluation of the best drop of the given tetromino on the board with the given depth.
+// The board's size is part of the key, as boards of different sizes may have the same hash.
+func tableKey(board *tetris.Board, tetromino tetris.Tetromino, depth int) uint64 {
+	// Multiplying by odd constants spreads the small numbers over all bits of the key.
+	return board.Hash() ^
+		(uint64(board.ClearedLines())<<16|uint64(tetromino)<<8|uint64(depth)+1)*0x9e3779b97f4a7c15 ^
+		(uint64(board.Width())<<32|uint64(board.Height()))*0xbf58476d1ce4e5b9
+}
+
+// lookup returns the stored evaluation with the given key if it is usable with the given alpha and beta,
+// i.e. if it is exact or if it is a bound that would be pruned anyway.
+func (t *table) lookup(key uint64, alpha, beta float64) (float64, bool) {
+	i := key & t.mask
+	lock := &t.locks[i%tableLocks]
+
+	lock.Lock()
+	e := t.entries[i]
+	lock.Unlock()
+
+	if e.key == key && (e.bound == boundExact ||
+		e.bound == boundLower && e.value >= beta ||
+		e.bound == boundUpper && e.value <= alpha) {
+		atomic.AddUint64(&t.hits, 1)
+		return e.value, true
+	}
+
+	atomic.AddUint64(&t.misses, 1)
+	return 0, false
+}
+
+// store stores the given fail-soft evaluation, which was computed with the given alpha and beta, with the given key.
+func (t *table) store(key uint64, value, alpha, beta float64) {
+	e := entry{key: key, value: value, bound: boundExact}
+	if value <= alpha {
+		e.bound = boundUpper
+	} else if value >= beta {
+		e.bound = boundLower
+	}
+
+	i := key & t.mask
+	lock := &t.locks[i%tableLocks]
+
+	lock.Lock()
+	t.entries[i] = e
+	lock.Unlock()
+}
+
+// clear removes all stored evaluations. The statistics are kept.
+func (t *table) clear() {
+	for i := range t.locks {
+		t.locks[i].Lock()
+	}
+	for i := range t.entries {
+		t.entries[i] = entry{}
+	}
+	for i := range t.locks {
+		t.locks[i].Unlock()
+	}
+}
+
+// stats returns the statistics of the table's lookups.
+func (t *table) stats() TableStats {
+	return TableStats{
+		Hits:   atomic.LoadUint64(&t.hits),
+		Misses: atomic.LoadUint64(&t.misses),
+	}
+}
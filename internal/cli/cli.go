@@ -4,53 +4,188 @@ package cli
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
 	"github.com/ozhi/tetris-ai/internal/tetris"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 // CLI is the command-line interface of Tetris-ai.
-// CLI encapsulates an AI that plays tetris and visualization logic.
+// CLI encapsulates a game that is played by a player and visualization logic.
 // The zero value of CLI is not usable, function New should be used to create one.
 type CLI struct {
-	ai *ai.AI
+	board *tetris.Board
+
+	// playerName and randomizerName are the names of the player and randomizer of the game.
+	// seed is the seed of the randomizer's and the player's random numbers, with which the game can be replayed.
+	// previews is the number of tetrominoes after the current one that the player knows.
+	// hints is true if the AI's suggested move is printed before each move, see CLI.SetHints.
+	playerName     string
+	randomizerName string
+	seed           int64
+	previews       int
+	hints          bool
 }
 
-// New creates and initializes a new CLI.
+// New creates and initializes a new CLI with a board of the default size.
 func New() *CLI {
+	return NewWithSize(tetris.DefaultBoardWidth, tetris.DefaultBoardHeight)
+}
+
+// NewWithSize creates and initializes a new CLI with a board with the given number of columns and rows.
+// The game is played by the AI, see ai.Name.
+// NewWithSize panics if the board size is invalid.
+func NewWithSize(width, height int) *CLI {
 	return &CLI{
-		ai: ai.New(),
+		board:          tetris.NewBoardWithSize(width, height),
+		playerName:     ai.Name,
+		randomizerName: tetris.RandomizerUniform,
+		seed:           time.Now().UnixNano(),
+		previews:       1,
+	}
+}
+
+// SetSeed seeds the randomizer and the player, so that the game with the given seed can be replayed.
+// By default, the time is used as seed.
+func (cli *CLI) SetSeed(seed int64) {
+	cli.seed = seed
+}
+
+// Seed returns the seed of the game, see CLI.SetSeed.
+func (cli *CLI) Seed() int64 {
+	return cli.seed
+}
+
+// SetRandomizer sets the randomizer with the given name, see tetris.RandomizerNames, to deal the tetrominoes.
+// SetRandomizer returns error if there is no randomizer with that name.
+func (cli *CLI) SetRandomizer(name string) error {
+	if _, err := tetris.NewRandomizer(name, rand.New(rand.NewSource(cli.seed))); err != nil {
+		return fmt.Errorf("CLI.SetRandomizer: %s", err)
+	}
+
+	cli.randomizerName = name
+	return nil
+}
+
+// SetPlayer sets the player with the given name, see player.Names, to play the game.
+// SetPlayer returns error if there is no player with that name.
+func (cli *CLI) SetPlayer(name string) error {
+	if _, err := player.New(name, cli.seed); err != nil {
+		return fmt.Errorf("CLI.SetPlayer: %s", err)
+	}
+
+	cli.playerName = name
+	return nil
+}
+
+// SetPreviews sets the number of tetrominoes after the current one that are shown and known to the player,
+// see ai.BeamName for a player that plans with all of them. By default, one tetromino is previewed.
+// SetPreviews returns error if the number is less than one.
+func (cli *CLI) SetPreviews(previews int) error {
+	if previews < 1 {
+		return fmt.Errorf("CLI.SetPreviews: invalid number of previews %d, expected at least 1", previews)
 	}
+
+	cli.previews = previews
+	return nil
+}
+
+// SetHints sets whether the AI's best move of each tetromino is printed as a hint before the player moves,
+// with the accuracy with which the player has matched the hints so far. By default, there are no hints.
+// As the CLI is only played by players like the AI, the accuracy only measures how often the player agrees
+// with the hinting AI, e.g. how often MCTS chooses the same moves as minimax.
+func (cli *CLI) SetHints(hints bool) {
+	cli.hints = hints
 }
 
-// Start starts the AI's game.
+// Start starts the game and plays it until it is over.
 func (cli *CLI) Start() {
-	cli.ai.SetNext(tetris.RandomTetromino())
+	// The names have been checked when they were set.
+	randomizer, _ := tetris.NewRandomizer(cli.randomizerName, rand.New(rand.NewSource(cli.seed)))
+	p, _ := player.New(cli.playerName, cli.seed)
+	game := player.NewGame(cli.board, p, randomizer, cli.previews)
+
+	var (
+		hinter   *ai.AI
+		accuracy player.Accuracy
+	)
+	if cli.hints {
+		// The hinter has the player's seed, so that the hints of a replayed game are the same,
+		// and searches the tetrominoes that the game's randomizer deals, like the player.
+		hinter = ai.NewWithSize(cli.board.Width(), cli.board.Height())
+		hinter.SetHoldEnabled(true)
+		hinter.SetSeed(cli.seed)
+		hinter.SetRandomizer(randomizer)
+	}
 
 	for {
-		board := cli.ai.Board()
-		printBoard(board)
+		cli.printGame(game)
 		// time.Sleep(100 * time.Millisecond)
 
-		err := cli.ai.DropSetNext(tetris.RandomTetromino())
+		var (
+			hint *player.Move
+			// The hinter always holds into the empty hold slot, which the player does not have to,
+			// so such hints are not compared with the player's moves.
+			counted = game.Board().Held() != tetris.TetrominoEmpty
+		)
+		if hinter != nil {
+			if move, err := hinter.Hint(game.Board(), game.Current(), game.Queue()); err == nil {
+				hint = &move
+				printHint(move, accuracy)
+			}
+		}
+
+		err := game.Play()
 		if err != nil {
 			break
 		}
+		if hint != nil && counted {
+			accuracy.Record(*hint, game.LastMove())
+		}
+	}
+
+	board := game.Board()
+	cli.printGame(game)
+	fmt.Printf("Game over after %d tetrominoes, seed %d\n", board.DroppedTetrominoes(), cli.seed)
+	if cli.hints {
+		fmt.Printf("The player agreed with %d of %d hints of the AI (%.0f%%)\n",
+			accuracy.Matches, accuracy.Hints, 100*accuracy.Rate())
+	}
+}
+
+// printHint prints the rows and columns of the cells in which the hinted move places its tetromino
+// and the accuracy with which the player has matched the hints so far.
+func printHint(hint player.Move, accuracy player.Accuracy) {
+	piece := hint.Placement.Piece
+
+	// The cells are sorted from top to bottom, so only the columns have to be compared.
+	cells := piece.Cells()
+	top, bottom, left, right := cells[0].Row, cells[len(cells)-1].Row, cells[0].Col, cells[0].Col
+	for _, cell := range cells[1:] {
+		if cell.Col < left {
+			left = cell.Col
+		}
+		if cell.Col > right {
+			right = cell.Col
+		}
 	}
 
-	printBoard(cli.ai.Board())
-	fmt.Println("Game over")
+	hold := ""
+	if hint.Hold {
+		hold = "hold, then "
+	}
+	fmt.Printf("hint: %splace %s in orientation %s at rows %d-%d, columns %d-%d   matched: %d/%d (%.0f%%)\n",
+		hold, piece.Tetromino, piece.Orientation, top, bottom, left, right,
+		accuracy.Matches, accuracy.Hints, 100*accuracy.Rate())
 }
 
-func printBoard(board *tetris.Board) {
+func (cli *CLI) printGame(game *player.Game) {
 	const clearScreen = "\033[2J"
 	fmt.Println(clearScreen)
 
+	board := game.Board()
 	for row := 0; row < board.Height(); row++ {
 		for col := 0; col < board.Width(); col++ {
 			if board.At(row, col) == tetris.TetrominoEmpty {
@@ -62,8 +197,18 @@ func printBoard(board *tetris.Board) {
 		fmt.Println()
 	}
 
+	// Only the last digit of each column number is printed to keep the columns aligned.
 	for col := 0; col < board.Width(); col++ {
-		fmt.Print(col)
+		fmt.Print(col % 10)
+	}
+	held := "-"
+	if board.Held() != tetris.TetrominoEmpty {
+		held = board.Held().String()
+	}
+	var next strings.Builder
+	for _, tetromino := range game.Queue() {
+		next.WriteString(tetromino.String())
 	}
-	fmt.Printf("   %d\n", board.ClearedLines())
+	fmt.Printf("   lines: %d   score: %d   level: %d   hold: %s   next: %s   seed: %d\n",
+		board.ClearedLines(), board.Score(), board.Level(), held, next.String(), cli.seed)
 }
@@ -2,6 +2,8 @@ package gui
 
 import (
 	"fmt"
+	"image/color"
+	"strings"
 	"time"
 
 	"github.com/ozhi/tetris-ai/internal/tetris"
@@ -56,10 +58,17 @@ func (gui *GUI) playScreen() *ebiten.Image {
 	draw(image, gui.automaticModeButtonImage(), gui.visualization.boardWidth, gui.visualization.titleBarHeight)
 	draw(image, gui.nextTetrominoButtonImage(), gui.visualization.boardWidth, gui.visualization.titleBarHeight+gui.visualization.buttonSize)
 
-	strings := []string{
-		fmt.Sprintf("Dropped: %d", gui.ai.Board().DroppedTetrominoes()),
-		fmt.Sprintf("Cleared lines: %d", gui.ai.Board().ClearedLines()),
+	board := gui.game.Board()
+	strings := [statsLinesCount]string{
+		fmt.Sprintf("Dropped: %d", board.DroppedTetrominoes()),
+		fmt.Sprintf("Lines: %d   Level: %d", board.ClearedLines(), board.Level()),
+		fmt.Sprintf("Score: %d", board.Score()),
 		fmt.Sprintf("Time: %s", displayTime(time.Since(gui.gameStart))),
+		fmt.Sprintf("Player: %s", gui.playerName),
+		fmt.Sprintf("Randomizer: %s", gui.randomizerName),
+		fmt.Sprintf("Seed: %d", gui.seed),
+		fmt.Sprintf("Next: %s", queueString(gui.game.Queue())),
+		fmt.Sprintf("Hints: %s", gui.hintsString()),
 	}
 	for i := range strings {
 
@@ -68,10 +77,12 @@ func (gui *GUI) playScreen() *ebiten.Image {
 			strings[i],
 			gui.visualization.font.normal,
 			gui.visualization.boardWidth+10,
-			gui.visualization.titleBarHeight+2*gui.visualization.buttonSize+(i+1)*30,
+			gui.visualization.titleBarHeight+2*gui.visualization.buttonSize+(i+1)*statsLineHeight,
 			gui.visualization.textColor)
 	}
 
+	draw(image, gui.heldTetrominoImage(), gui.visualization.boardWidth, gui.visualization.titleBarHeight+2*gui.visualization.buttonSize+(len(strings)+1)*statsLineHeight)
+
 	return image
 }
 
@@ -124,12 +135,12 @@ func (gui *GUI) titleImage() *ebiten.Image {
 func (gui *GUI) boardImage() *ebiten.Image {
 	cellSize := gui.visualization.cellSize
 
-	image, _ := ebiten.NewImage(10*cellSize, 20*cellSize, ebiten.FilterDefault)
+	image, _ := ebiten.NewImage(gui.visualization.boardWidth, gui.visualization.boardHeight, ebiten.FilterDefault)
 	image.Fill(gui.visualization.boardBackground)
 
 	cell, _ := ebiten.NewImage(cellSize-1, cellSize-1, ebiten.FilterDefault)
 
-	board := gui.ai.Board()
+	board := gui.game.Board()
 	for row := 0; row < board.Height(); row++ {
 		for col := 0; col < board.Width(); col++ {
 			cell.Fill(gui.visualization.tetrominoColors[board.At(row, col)])
@@ -137,6 +148,26 @@ func (gui *GUI) boardImage() *ebiten.Image {
 		}
 	}
 
+	if gui.humanMode {
+		cell.Fill(gui.visualization.ghostColor)
+		for _, c := range board.Ghost(gui.piece).Cells() {
+			draw(image, cell, c.Col*cellSize, c.Row*cellSize)
+		}
+
+		if gui.hint != nil {
+			outline := outlineImage(cellSize-1, gui.visualization.hintColor)
+			for _, c := range gui.hint.Placement.Piece.Cells() {
+				draw(image, outline, c.Col*cellSize, c.Row*cellSize)
+			}
+			outline.Dispose()
+		}
+
+		cell.Fill(gui.visualization.tetrominoColors[gui.piece.Tetromino])
+		for _, c := range gui.piece.Cells() {
+			draw(image, cell, c.Col*cellSize, c.Row*cellSize)
+		}
+	}
+
 	cell.Dispose()
 	return image
 }
@@ -177,12 +208,81 @@ func (gui *GUI) nextTetrominoButtonImage() *ebiten.Image {
 	foreground, _ := ebiten.NewImage(buttonSize-6, buttonSize-6, ebiten.FilterDefault)
 	_ = foreground.Fill(gui.visualization.background)
 
-	draw(foreground, gui.tetrominoImage(gui.nextTetromino), 0, 0)
+	// The human player moves the current tetromino on the board, so the one after it is shown instead.
+	next := gui.game.Current()
+	if gui.humanMode {
+		next = gui.game.Queue()[0]
+	}
+	draw(foreground, gui.tetrominoImage(next), 0, 0)
 	draw(borderedImage, foreground, 3, 3)
 
 	return borderedImage
 }
 
+// heldTetrominoImage creates the image of the tetromino in the hold slot.
+func (gui *GUI) heldTetrominoImage() *ebiten.Image {
+	buttonSize := gui.visualization.buttonSize
+
+	image, _ := ebiten.NewImage(buttonSize, buttonSize+heldLabelHeight, ebiten.FilterDefault)
+	_ = image.Fill(gui.visualization.background)
+
+	text.Draw(
+		image,
+		"Hold:",
+		gui.visualization.font.normal,
+		10,
+		20,
+		gui.visualization.textColor)
+	draw(image, gui.tetrominoImage(gui.game.Board().Held()), 0, heldLabelHeight)
+
+	return image
+}
+
+// hintsString returns whether hint mode is on and how often the human player has matched the suggested moves.
+// If the suggested move holds first, the human player is reminded to do so.
+func (gui *GUI) hintsString() string {
+	if !gui.hintMode {
+		return "off"
+	}
+
+	hints := fmt.Sprintf("%d/%d matched (%.0f%%)", gui.accuracy.Matches, gui.accuracy.Hints, 100*gui.accuracy.Rate())
+	if gui.humanMode && gui.hint != nil && gui.hint.Hold {
+		hints += ", hold"
+	}
+	return hints
+}
+
+// outlineImage returns a transparent square image of the given size with a border of the given color.
+func outlineImage(size int, c color.Color) *ebiten.Image {
+	const borderWidth = 3
+
+	image, _ := ebiten.NewImage(size, size, ebiten.FilterDefault)
+
+	horizontal, _ := ebiten.NewImage(size, borderWidth, ebiten.FilterDefault)
+	_ = horizontal.Fill(c)
+	vertical, _ := ebiten.NewImage(borderWidth, size, ebiten.FilterDefault)
+	_ = vertical.Fill(c)
+
+	draw(image, horizontal, 0, 0)
+	draw(image, horizontal, 0, size-borderWidth)
+	draw(image, vertical, 0, 0)
+	draw(image, vertical, size-borderWidth, 0)
+
+	horizontal.Dispose()
+	vertical.Dispose()
+
+	return image
+}
+
+// queueString returns the letters of the given tetrominoes, separated by spaces.
+func queueString(queue []tetris.Tetromino) string {
+	letters := make([]string, len(queue))
+	for i, tetromino := range queue {
+		letters[i] = tetromino.String()
+	}
+	return strings.Join(letters, " ")
+}
+
 // draw is a helper that draws the given image on top of the target with an offset.
 func draw(target, image *ebiten.Image, offsetX, offsetY int) error {
 	opts := ebiten.DrawImageOptions{}
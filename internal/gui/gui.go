@@ -8,49 +8,175 @@ import (
 
 	"github.com/hajimehoshi/ebiten"
 	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
 	"github.com/ozhi/tetris-ai/internal/tetris"
 )
 
-func init() {
-	rand.Seed(time.Now().UTC().UnixNano())
-}
-
 // GUI is the graphical user interface of Tetris-AI.
-// GUI encapsulates an AI that plays tetris and visualization logic.
+// GUI encapsulates a game that is played by a player and visualization logic.
 // The zero value of GUI is not usable, function New should be used to create one.
 type GUI struct {
 	screen        Screen
 	visualization *visualizationOptions
 
-	ai            *ai.AI
-	nextTetromino tetris.Tetromino
+	game *player.Game
+
+	// width and height are the size of the game's board.
+	// playerName and randomizerName are the names of the game's player and randomizer.
+	// seed is the seed of the randomizer's and the player's random numbers, with which the game can be replayed.
+	// previews is the number of tetrominoes after the current one that the player knows.
+	width          int
+	height         int
+	playerName     string
+	randomizerName string
+	seed           int64
+	previews       int
 
 	automaticMode         bool
 	automaticModeTurnedOn chan struct{}
 
+	// humanMode is true if the human player moves the next tetromino instead of the AI.
+	// piece is the tetromino that is being moved by the human player.
+	// lastFall is when the piece last fell by a row because of gravity.
+	humanMode bool
+	piece     tetris.Piece
+	lastFall  time.Time
+
+	// hintMode is true if the hinter AI suggests moves to the human player, see GUI.SetHints.
+	// The hinter searches in the background, receiving requests on hintRequests and sending the hints on hints.
+	// hintTurn identifies the last request, see hintRequest.
+	// hint is the suggested move of the human player's piece, if there is one, whose placement is drawn on the board.
+	// turnHint is the move suggested before the human player held the current tetromino, if at all,
+	// and held is true if they did. The human player's move is compared with turnHint in accuracy.
+	hintMode     bool
+	hinter       *ai.AI
+	hintRequests chan hintRequest
+	hints        chan hintResult
+	hintTurn     int
+	hint         *player.Move
+	turnHint     *player.Move
+	held         bool
+	accuracy     player.Accuracy
+
 	gameStart time.Time
 }
 
-// New creates and initializes a new GUI.
+// New creates and initializes a new GUI with a board of the default size.
 func New() *GUI {
-	tetromino := tetris.RandomTetromino()
-
-	ai := ai.New()
-	ai.SetNext(tetromino)
+	return NewWithSize(tetris.DefaultBoardWidth, tetris.DefaultBoardHeight)
+}
 
-	return &GUI{
+// NewWithSize creates and initializes a new GUI with a board with the given number of columns and rows.
+// The game is played by the AI, see ai.Name.
+// NewWithSize panics if the board size is invalid.
+func NewWithSize(width, height int) *GUI {
+	gui := &GUI{
 		screen:        ScreenWelcome,
-		visualization: getvisualizationOptions(),
+		visualization: getvisualizationOptions(width, height),
 
-		ai:            ai,
-		nextTetromino: tetromino,
+		width:          width,
+		height:         height,
+		playerName:     ai.Name,
+		randomizerName: tetris.RandomizerUniform,
+		seed:           time.Now().UnixNano(),
+		previews:       1,
 
 		automaticMode:         false,
 		automaticModeTurnedOn: make(chan struct{}),
+
+		hintRequests: make(chan hintRequest, 1),
+		hints:        make(chan hintResult, 1),
+	}
+	gui.hinter = ai.NewWithSize(width, height)
+	gui.hinter.SetHoldEnabled(true)
+	gui.newGame()
+
+	return gui
+}
+
+// newGame creates the game with the GUI's player, randomizer and seed, whose names are expected to be valid.
+func (gui *GUI) newGame() {
+	randomizer, _ := tetris.NewRandomizer(gui.randomizerName, rand.New(rand.NewSource(gui.seed)))
+	p, _ := player.New(gui.playerName, gui.seed)
+	gui.game = player.NewGame(tetris.NewBoardWithSize(gui.width, gui.height), p, randomizer, gui.previews)
+	gui.accuracy = player.Accuracy{}
+}
+
+// SetSeed seeds the randomizer and the player, so that the game with the given seed can be replayed.
+// By default, the time is used as seed.
+// SetSeed should be called before the game starts, as it starts the game again.
+func (gui *GUI) SetSeed(seed int64) {
+	gui.seed = seed
+	gui.newGame()
+}
+
+// SetPlayer sets the player with the given name, see player.Names, to play the game.
+// SetPlayer should be called before the game starts, as it starts the game again.
+// SetPlayer returns error if there is no player with that name.
+func (gui *GUI) SetPlayer(name string) error {
+	if _, err := player.New(name, gui.seed); err != nil {
+		return fmt.Errorf("GUI.SetPlayer: %s", err)
+	}
+
+	gui.playerName = name
+	gui.newGame()
+	return nil
+}
+
+// SetPreviews sets the number of tetrominoes after the current one that are shown and known to the player,
+// see ai.BeamName for a player that plans with all of them. By default, one tetromino is previewed.
+// SetPreviews should be called before the game starts, as it starts the game again.
+// SetPreviews returns error if the number is less than one.
+func (gui *GUI) SetPreviews(previews int) error {
+	if previews < 1 {
+		return fmt.Errorf("GUI.SetPreviews: invalid number of previews %d, expected at least 1", previews)
+	}
+
+	gui.previews = previews
+	gui.newGame()
+	return nil
+}
+
+// SetHints sets whether the AI suggests moves to the human player: its best placement of the human player's piece
+// is outlined on the board and the accuracy with which the human player follows the suggestions is shown.
+// Hints can also be toggled while playing. By default, there are no hints.
+func (gui *GUI) SetHints(hints bool) {
+	gui.hintMode = hints
+}
+
+// SetRandomizer sets the randomizer with the given name, see tetris.RandomizerNames, to deal the next tetrominoes.
+// The randomizer draws its random numbers from a source with the game's seed.
+// If the game has not started yet, it is started again, so that the first tetrominoes are dealt by the new randomizer.
+// SetRandomizer returns error if there is no randomizer with that name.
+func (gui *GUI) SetRandomizer(name string) error {
+	randomizer, err := tetris.NewRandomizer(name, rand.New(rand.NewSource(gui.seed)))
+	if err != nil {
+		return fmt.Errorf("GUI.SetRandomizer: %s", err)
+	}
+
+	gui.randomizerName = name
+
+	if gui.screen == ScreenWelcome {
+		gui.newGame()
+	} else {
+		gui.game.SetRandomizer(randomizer)
+	}
+
+	return nil
+}
+
+// cycleRandomizer switches to the randomizer after the current one in tetris.RandomizerNames.
+func (gui *GUI) cycleRandomizer() {
+	names := tetris.RandomizerNames()
+	for i, name := range names {
+		if name == gui.randomizerName {
+			_ = gui.SetRandomizer(names[(i+1)%len(names)])
+			return
+		}
 	}
 }
 
-// Start starts the AI's game and the visualization loop.
+// Start starts the game and the visualization loop.
 func (gui *GUI) Start() error {
 	update := func(screen *ebiten.Image) error {
 		gui.update()
@@ -63,6 +189,7 @@ func (gui *GUI) Start() error {
 	}
 
 	go gui.automaticallyDropTetrominoes()
+	go gui.findHints()
 
 	err := ebiten.Run(
 		update,
@@ -77,7 +204,7 @@ func (gui *GUI) Start() error {
 	return nil
 }
 
-// automaticallyDropTetrominoes generates random tetrominoes and tells the AI to drop them.
+// automaticallyDropTetrominoes lets the player play the game's tetrominoes.
 // Only does so if automatic mode is set, otherwise blocks on the gui.automaticModeTurnedOn channel.
 func (gui *GUI) automaticallyDropTetrominoes() {
 	for {
@@ -85,10 +212,9 @@ func (gui *GUI) automaticallyDropTetrominoes() {
 			<-gui.automaticModeTurnedOn
 		}
 
-		if err := gui.ai.DropSetNext(gui.nextTetromino); err != nil {
-			fmt.Printf("AI could not drop tetromino: %s", err)
+		if err := gui.game.Play(); err != nil {
+			fmt.Printf("Player could not drop tetromino: %s", err)
 			break
 		}
-		gui.nextTetromino = tetris.RandomTetromino()
 	}
 }
@@ -0,0 +1,222 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Keys that are held down are repeated after keyRepeatDelay frames, every keyRepeatInterval frames.
+const (
+	keyRepeatDelay    = 10
+	keyRepeatInterval = 3
+)
+
+// toggleHumanMode switches between the human player and the AI playing the next tetromino.
+// Human mode can not be turned on while the AI plays in automatic mode or after the game is over.
+func (gui *GUI) toggleHumanMode() {
+	if gui.automaticMode || gui.game.Board().GameOver() {
+		return
+	}
+
+	// The player continues with the tetromino that the human player was moving, which is the game's current one.
+	gui.humanMode = !gui.humanMode
+	if gui.humanMode {
+		gui.held, gui.turnHint = false, nil
+		gui.spawn(gui.game.Current())
+		gui.suggest()
+	}
+}
+
+// toggleHintMode switches the AI's suggestions to the human player on and off, see GUI.SetHints.
+func (gui *GUI) toggleHintMode() {
+	gui.hintMode = !gui.hintMode
+	gui.turnHint = nil
+	gui.suggest()
+}
+
+// hintRequest is a request to the hinter to suggest the move of the current tetromino on a copy of the game's board.
+// turn identifies the request, so that the hints of the pieces that have already been locked or held are ignored.
+// counted is true if the hint is compared with the human player's move, see suggest.
+type hintRequest struct {
+	turn    int
+	board   *tetris.Board
+	current tetris.Tetromino
+	queue   []tetris.Tetromino
+	counted bool
+}
+
+// hintResult is the move suggested by the hinter for the request, or the error if it could not suggest one.
+type hintResult struct {
+	request hintRequest
+	move    player.Move
+	err     error
+}
+
+// suggest requests the hinter to suggest the move of the game's current tetromino to the human player,
+// if hint mode is on, and hides the previous hint. The hint is shown when the hinter finds it, see receiveHint.
+// The first hint before the human player holds is the one with which the human player's move is compared,
+// unless the hold slot is empty: then the hinter always holds, which the human player does not have to.
+func (gui *GUI) suggest() {
+	gui.hint = nil
+	gui.hintTurn++
+	if !gui.hintMode || !gui.humanMode {
+		return
+	}
+
+	board := gui.game.Board()
+	request := hintRequest{
+		turn:    gui.hintTurn,
+		board:   tetris.NewBoardFromBoard(board),
+		current: gui.game.Current(),
+		queue:   append([]tetris.Tetromino(nil), gui.game.Queue()...),
+		counted: !gui.held && board.Held() != tetris.TetrominoEmpty,
+	}
+
+	// A request that the hinter has not started yet is replaced, as its piece has already been locked or held.
+	select {
+	case <-gui.hintRequests:
+	default:
+	}
+	gui.hintRequests <- request
+}
+
+// receiveHint shows the hint found by the hinter, if it is for the human player's current piece.
+func (gui *GUI) receiveHint() {
+	select {
+	case result := <-gui.hints:
+		if result.request.turn != gui.hintTurn || result.err != nil {
+			// The hint is outdated or the current tetromino can not be placed, so there is nothing to suggest.
+			return
+		}
+
+		gui.hint = &result.move
+		if result.request.counted {
+			gui.turnHint = &result.move
+		}
+
+	default:
+	}
+}
+
+// findHints lets the hinter search the moves requested by suggest in the background,
+// so that the GUI does not freeze while it searches.
+func (gui *GUI) findHints() {
+	for request := range gui.hintRequests {
+		move, err := gui.hinter.Hint(request.board, request.current, request.queue)
+		gui.hints <- hintResult{request: request, move: move, err: err}
+	}
+}
+
+// record compares the human player's move, which locks the given piece, with the move suggested for it, if any.
+func (gui *GUI) record(piece tetris.Piece) {
+	if gui.turnHint == nil {
+		return
+	}
+
+	played := player.Move{Hold: gui.held, Placement: tetris.Placement{Piece: piece}}
+	gui.accuracy.Record(*gui.turnHint, played)
+}
+
+// updateHumanMode moves the human player's piece according to user input.
+func (gui *GUI) updateHumanMode() {
+	board := gui.game.Board()
+
+	switch {
+	case isKeyRepeated(ebiten.KeyLeft):
+		gui.piece, _ = board.MoveLeft(gui.piece)
+
+	case isKeyRepeated(ebiten.KeyRight):
+		gui.piece, _ = board.MoveRight(gui.piece)
+
+	case isKeyRepeated(ebiten.KeyDown):
+		gui.piece, _ = board.SoftDrop(gui.piece)
+		gui.lastFall = time.Now()
+
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp), inpututil.IsKeyJustPressed(ebiten.KeyX):
+		gui.piece, _ = board.Rotate(gui.piece, tetris.TurnClockwise)
+
+	case inpututil.IsKeyJustPressed(ebiten.KeyZ):
+		gui.piece, _ = board.Rotate(gui.piece, tetris.TurnCounterClockwise)
+
+	case inpututil.IsKeyJustPressed(ebiten.KeyS):
+		gui.piece, _ = board.Rotate(gui.piece, tetris.Turn180)
+
+	case inpututil.IsKeyJustPressed(ebiten.KeyC):
+		gui.hold()
+
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		gui.record(board.Ghost(gui.piece))
+		board.HardDrop(gui.piece)
+		gui.spawnNext()
+		return
+	}
+
+	gui.fall()
+}
+
+// fall moves the human player's piece one row down when the gravity of the current level says so.
+// A piece that can not fall any more is locked in place.
+func (gui *GUI) fall() {
+	if time.Since(gui.lastFall) < gui.game.Board().Gravity() {
+		return
+	}
+	gui.lastFall = time.Now()
+
+	board := gui.game.Board()
+	piece, ok := board.MoveDown(gui.piece)
+	if ok {
+		gui.piece = piece
+		return
+	}
+
+	gui.record(gui.piece)
+	board.Lock(gui.piece)
+	gui.spawnNext()
+}
+
+// spawnNext spawns the next tetromino as the human player's piece after the previous one has been locked.
+func (gui *GUI) spawnNext() {
+	gui.game.Advance()
+	gui.held, gui.turnHint = false, nil
+	gui.spawn(gui.game.Current())
+	gui.suggest()
+}
+
+// hold swaps the human player's piece with the held tetromino, if the hold slot can be used.
+func (gui *GUI) hold() {
+	if gui.game.Hold() {
+		gui.held = true
+		gui.spawn(gui.game.Current())
+		gui.suggest()
+	}
+}
+
+// spawn spawns the given tetromino as the human player's piece.
+// If the game ends, human mode is turned off.
+func (gui *GUI) spawn(tetromino tetris.Tetromino) {
+	piece, err := gui.game.Board().Spawn(tetromino)
+	if err != nil {
+		fmt.Printf("Could not spawn tetromino: %s", err)
+		gui.humanMode = false
+		return
+	}
+
+	gui.piece = piece
+	gui.lastFall = time.Now()
+}
+
+// isKeyRepeated returns true if the given key has just been pressed
+// or has been held down long enough to be repeated at the current frame.
+func isKeyRepeated(key ebiten.Key) bool {
+	duration := inpututil.KeyPressDuration(key)
+	if duration == 1 {
+		return true
+	}
+
+	return duration >= keyRepeatDelay && (duration-keyRepeatDelay)%keyRepeatInterval == 0
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/inpututil"
-	"github.com/ozhi/tetris-ai/internal/tetris"
 )
 
 // update updates the state of the GUI according to user input.
@@ -19,6 +18,24 @@ func (gui *GUI) update() {
 		}
 
 	case ScreenPlay:
+		if inpututil.IsKeyJustReleased(ebiten.KeyH) {
+			gui.toggleHumanMode()
+		}
+		if inpututil.IsKeyJustReleased(ebiten.KeyG) {
+			gui.toggleHintMode()
+		}
+
+		if gui.humanMode {
+			gui.receiveHint()
+			gui.updateHumanMode()
+			return
+		}
+
+		// The randomizer can not be changed while the player plays in automatic mode, which also deals tetrominoes.
+		if inpututil.IsKeyJustReleased(ebiten.KeyR) && !gui.automaticMode {
+			gui.cycleRandomizer()
+		}
+
 		if gui.isAutomaticModeJustToggled() {
 			gui.automaticMode = !gui.automaticMode
 			if gui.automaticMode {
@@ -28,8 +45,9 @@ func (gui *GUI) update() {
 
 		if !gui.automaticMode {
 			if gui.isNextTetrominoJustPressed() {
-				gui.nextTetromino = tetris.RandomTetromino()
-				gui.ai.DropSetNext(gui.nextTetromino)
+				if err := gui.game.Play(); err != nil {
+					fmt.Printf("Player could not drop tetromino: %s", err)
+				}
 			}
 		}
 
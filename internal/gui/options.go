@@ -40,17 +40,50 @@ type visualizationOptions struct {
 	background        color.Color
 	boardBackground   color.Color
 	borderColor       color.Color
+	ghostColor        color.Color
+	hintColor         color.Color
 }
 
-// getvisualizationOptions returns the visualizationOptions that the GUI will use.
-func getvisualizationOptions() *visualizationOptions {
-	cellSize := 40
-	titleBarHeight := 3 * cellSize
-	boardWidth, boardHeight := 10*cellSize, 20*cellSize
-	buttonSize := 5 * cellSize
+// maxCellSize is the size of a board cell on the screen for boards of the default height.
+// Cells of taller boards are shrunk so that the board fits on the screen, but never below minCellSize,
+// as each cell is drawn one pixel smaller than its size.
+const (
+	maxCellSize = 40
+	minCellSize = 2
+)
+
+// The statistics are shown in statsLinesCount lines of statsLineHeight pixels next to the board,
+// between the buttons and the held tetromino, whose label is heldLabelHeight pixels high.
+const (
+	statsLinesCount = 9
+	statsLineHeight = 25
+	heldLabelHeight = 30
+)
+
+// getvisualizationOptions returns the visualizationOptions that the GUI will use
+// for a board with the given number of columns and rows.
+func getvisualizationOptions(columns, rows int) *visualizationOptions {
+	cellSize := maxCellSize
+	if rows > tetris.DefaultBoardHeight {
+		cellSize = maxCellSize * tetris.DefaultBoardHeight / rows
+	}
+	if cellSize < minCellSize {
+		cellSize = minCellSize
+	}
+
+	titleBarHeight := 3 * maxCellSize
+	boardWidth, boardHeight := columns*cellSize, rows*cellSize
+	buttonSize := 5 * maxCellSize
+
+	// The screen is never shorter than the buttons, statistics and held tetromino next to the board.
+	screenHeight := titleBarHeight + boardHeight
+	sidePanelHeight := titleBarHeight + 3*buttonSize + (statsLinesCount+1)*statsLineHeight + heldLabelHeight
+	if screenHeight < sidePanelHeight {
+		screenHeight = sidePanelHeight
+	}
 
 	return &visualizationOptions{
-		logoSize: 8 * cellSize,
+		logoSize: 8 * maxCellSize,
 		cellSize: cellSize,
 
 		titleBarHeight: titleBarHeight,
@@ -59,7 +92,7 @@ func getvisualizationOptions() *visualizationOptions {
 		buttonSize:     buttonSize,
 
 		screenWidth:  boardWidth + buttonSize,
-		screenHeight: titleBarHeight + boardHeight,
+		screenHeight: screenHeight,
 
 		windowTitle: "Tetris AI",
 		scale:       1,
@@ -72,6 +105,8 @@ func getvisualizationOptions() *visualizationOptions {
 		background:        color.RGBA{0, 0, 0, 255},
 		boardBackground:   color.RGBA{14, 17, 17, 255},
 		borderColor:       color.RGBA{100, 100, 100, 255},
+		ghostColor:        color.RGBA{45, 50, 50, 255},
+		hintColor:         color.RGBA{230, 230, 230, 255},
 	}
 }
 
@@ -0,0 +1,25 @@
+package player
+
+// Accuracy counts how often the moves played in a game matched the moves suggested for them,
+// e.g. by an AI giving hints to a human player. The zero value of Accuracy is ready to use.
+type Accuracy struct {
+	// Hints is the number of moves that were played after a suggestion and Matches is how many of them matched it.
+	Hints   int
+	Matches int
+}
+
+// Record counts the played move, for which the hinted move was suggested, see Move.Matches.
+func (a *Accuracy) Record(hint, played Move) {
+	a.Hints++
+	if hint.Matches(played) {
+		a.Matches++
+	}
+}
+
+// Rate returns the fraction of the hinted moves that were matched, or 0 if there were none.
+func (a Accuracy) Rate() float64 {
+	if a.Hints == 0 {
+		return 0
+	}
+	return float64(a.Matches) / float64(a.Hints)
+}
@@ -0,0 +1,40 @@
+package player_test
+
+import (
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// move returns the move that locks the given piece without holding.
+func move(piece tetris.Piece) player.Move {
+	return player.Move{Placement: tetris.Placement{Piece: piece}}
+}
+
+func TestMoveMatches(t *testing.T) {
+	flat := move(tetris.Piece{Tetromino: tetris.TetrominoI, Orientation: tetris.OrientationSpawn, Row: 5, Col: 0})
+
+	// The I rotated twice occupies the row below the one it spawns in, so it fills the same cells from a row higher.
+	assert.True(t, flat.Matches(move(tetris.Piece{Tetromino: tetris.TetrominoI, Orientation: tetris.OrientationTwo, Row: 4, Col: 0})))
+	assert.False(t, flat.Matches(move(tetris.Piece{Tetromino: tetris.TetrominoI, Orientation: tetris.OrientationSpawn, Row: 5, Col: 1})))
+
+	held := flat
+	held.Hold = true
+	assert.False(t, flat.Matches(held))
+}
+
+func TestAccuracy(t *testing.T) {
+	var accuracy player.Accuracy
+	assert.Equal(t, 0.0, accuracy.Rate())
+
+	hint := move(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 10, Col: 2})
+	accuracy.Record(hint, hint)
+	accuracy.Record(hint, move(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 10, Col: 4}))
+	accuracy.Record(hint, hint)
+	accuracy.Record(hint, player.Move{})
+
+	assert.Equal(t, player.Accuracy{Hints: 4, Matches: 2}, accuracy)
+	assert.Equal(t, 0.5, accuracy.Rate())
+}
@@ -0,0 +1,148 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Game is a game of tetris on a board, in which the tetrominoes are dealt by a randomizer and placed by a player.
+// The player knows the current tetromino and a queue of the next ones.
+// A human can also play the game's tetrominoes, see Game.Advance and Game.Hold.
+// The zero value of Game is not usable, NewGame should be used to create one.
+type Game struct {
+	board      *tetris.Board
+	player     Player
+	randomizer tetris.Randomizer
+
+	current tetris.Tetromino
+	queue   []tetris.Tetromino
+
+	// lastMove is the move that the player last played.
+	lastMove Move
+}
+
+// NewGame creates a new game on the given board, with the given player and randomizer
+// and a queue of the given number of next tetrominoes.
+// NewGame panics if the size of the queue is negative.
+func NewGame(board *tetris.Board, player Player, randomizer tetris.Randomizer, queueSize int) *Game {
+	if queueSize < 0 {
+		panic(fmt.Errorf("NewGame: invalid queue size %d provided", queueSize))
+	}
+
+	game := &Game{
+		board:      board,
+		player:     player,
+		randomizer: randomizer,
+		queue:      make([]tetris.Tetromino, queueSize),
+	}
+	game.observeRandomizer()
+
+	game.current = randomizer.Next()
+	for i := range game.queue {
+		game.queue[i] = randomizer.Next()
+	}
+
+	return game
+}
+
+// Board returns the board of the game.
+func (g *Game) Board() *tetris.Board {
+	return g.board
+}
+
+// Current returns the tetromino that is placed next.
+func (g *Game) Current() tetris.Tetromino {
+	return g.current
+}
+
+// Queue returns the tetrominoes that come after the current one, in order.
+// The returned slice must not be changed.
+func (g *Game) Queue() []tetris.Tetromino {
+	return g.queue
+}
+
+// LastMove returns the move that the player last played with Play, or the zero Move if it has not played yet.
+// The moves of a human are not recorded.
+func (g *Game) LastMove() Move {
+	return g.lastMove
+}
+
+// SetRandomizer sets the randomizer that deals the tetrominoes after the ones already in the queue.
+func (g *Game) SetRandomizer(randomizer tetris.Randomizer) {
+	g.randomizer = randomizer
+	g.observeRandomizer()
+}
+
+// observeRandomizer sets the game's randomizer to the player, if it is a RandomizerObserver,
+// and the game's board to the randomizer, if it is a tetris.BoardObserver.
+func (g *Game) observeRandomizer() {
+	if observer, ok := g.player.(RandomizerObserver); ok {
+		observer.SetRandomizer(g.randomizer)
+	}
+	if observer, ok := g.randomizer.(tetris.BoardObserver); ok {
+		observer.SetBoard(g.board)
+	}
+}
+
+// Play lets the player place the current tetromino, holding it first if the player decides so.
+// Play returns error if the player can not place the tetromino or if the player's move is not valid.
+// If the player can not place the tetromino because it can not be spawned, the board's game is over.
+func (g *Game) Play() error {
+	if g.board.GameOver() {
+		return fmt.Errorf("Game.Play: can not play: game is over")
+	}
+
+	move, err := g.player.Play(g.board, g.current, g.queue)
+	if err != nil {
+		// Spawning the tetromino ends the game if it is blocked, so that the game is not played any longer.
+		g.board.Spawn(g.current)
+		return fmt.Errorf("Game.Play: %s", err)
+	}
+
+	if move.Hold && !g.Hold() {
+		return fmt.Errorf("Game.Play: invalid move: the hold slot can not be used")
+	}
+
+	piece := move.Placement.Piece
+	if piece.Tetromino != g.current || !g.board.Fits(piece) {
+		return fmt.Errorf("Game.Play: invalid move: can not place %v", piece)
+	}
+
+	g.board.Place(piece)
+	g.lastMove = move
+	g.Advance()
+
+	return nil
+}
+
+// Hold puts the current tetromino in the hold slot and makes the tetromino taken out of it the current one
+// or, if the slot was empty, the first tetromino of the queue.
+// Hold returns false and does nothing if the hold slot can not be used.
+func (g *Game) Hold() bool {
+	if !g.board.CanHold() {
+		return false
+	}
+
+	held := g.board.Hold(g.current)
+	if held == tetris.TetrominoEmpty {
+		g.Advance()
+	} else {
+		g.current = held
+	}
+
+	return true
+}
+
+// Advance makes the first tetromino of the queue the current one and deals a new tetromino at the end of the queue.
+// Advance is called by Play and should be called after a human locks the current tetromino on the board.
+func (g *Game) Advance() {
+	if len(g.queue) == 0 {
+		g.current = g.randomizer.Next()
+		return
+	}
+
+	g.current = g.queue[0]
+	copy(g.queue, g.queue[1:])
+	g.queue[len(g.queue)-1] = g.randomizer.Next()
+}
@@ -0,0 +1,149 @@
+package player_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGame(queueSize int) *player.Game {
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	return player.NewGame(tetris.NewBoard(), player.NewRandom(1), randomizer, queueSize)
+}
+
+func TestGamePlayUntilGameOver(t *testing.T) {
+	game := newGame(1)
+
+	for i := 0; i < 1000; i++ {
+		current, next := game.Current(), game.Queue()[0]
+		if err := game.Play(); err != nil {
+			break
+		}
+		assert.Equal(t, next, game.Current())
+		assert.Equal(t, current, game.LastMove().Placement.Piece.Tetromino)
+		assert.Equal(t, i+1, game.Board().DroppedTetrominoes(), "tetromino %s", current)
+	}
+
+	assert.NotNil(t, game.Play())
+	assert.True(t, game.Board().DroppedTetrominoes() < 1000)
+}
+
+func TestGamePlayEndsGameWhenSpawnIsBlocked(t *testing.T) {
+	game := newGame(1)
+	board := game.Board()
+	// The top rows are filled up to the last two columns, so that they are not cleared.
+	for col := 0; col < board.Width()-2; col += 2 {
+		board.Lock(tetris.Piece{Tetromino: tetris.TetrominoO, Row: 0, Col: col})
+	}
+	assert.False(t, board.GameOver())
+
+	assert.NotNil(t, game.Play())
+	assert.True(t, board.GameOver())
+}
+
+func TestGameDealsTetrominoesInOrder(t *testing.T) {
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	var expected []tetris.Tetromino
+	for i := 0; i < 10; i++ {
+		expected = append(expected, randomizer.Next())
+	}
+
+	game := newGame(3)
+	assert.Equal(t, expected[0], game.Current())
+	assert.Equal(t, expected[1:4], game.Queue())
+
+	for i := 1; i < 7; i++ {
+		game.Advance()
+		assert.Equal(t, expected[i], game.Current())
+		assert.Equal(t, expected[i+1:i+4], game.Queue())
+	}
+}
+
+func TestGameHold(t *testing.T) {
+	game := newGame(1)
+	first, second := game.Current(), game.Queue()[0]
+
+	// Holding into the empty slot takes the next tetromino out of the queue.
+	assert.True(t, game.Hold())
+	assert.Equal(t, first, game.Board().Held())
+	assert.Equal(t, second, game.Current())
+	assert.False(t, game.Hold())
+
+	game.Board().HardDrop(game.Board().SpawnPiece(game.Current()))
+	game.Advance()
+	third := game.Current()
+
+	assert.True(t, game.Hold())
+	assert.Equal(t, third, game.Board().Held())
+	assert.Equal(t, first, game.Current())
+}
+
+func TestNew(t *testing.T) {
+	assert.Contains(t, player.Names(), player.RandomName)
+
+	p, err := player.New(player.RandomName, 1)
+	assert.Nil(t, err)
+	assert.NotNil(t, p)
+
+	_, err = player.New("unknown", 1)
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() { player.Register(player.RandomName, nil) })
+}
+
+// observer is a player that records the randomizer set by the game.
+type observer struct {
+	player.Player
+	randomizer tetris.Randomizer
+}
+
+func (o *observer) SetRandomizer(randomizer tetris.Randomizer) {
+	o.randomizer = randomizer
+}
+
+func TestGameSetsRandomizerToObserver(t *testing.T) {
+	first := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+	o := &observer{Player: player.NewRandom(1)}
+
+	game := player.NewGame(tetris.NewBoard(), o, first, 1)
+	assert.Equal(t, first, o.randomizer)
+
+	second := tetris.NewUniformRandomizer(rand.New(rand.NewSource(1)))
+	game.SetRandomizer(second)
+	assert.Equal(t, second, o.randomizer)
+}
+
+// boardRandomizer is a randomizer that records the board set by the game and deals I tetrominoes.
+type boardRandomizer struct {
+	board *tetris.Board
+
+	// dealtWithoutBoard is true if a tetromino was dealt before the board was set.
+	dealtWithoutBoard bool
+}
+
+func (r *boardRandomizer) SetBoard(board *tetris.Board) {
+	r.board = board
+}
+
+func (r *boardRandomizer) Next() tetris.Tetromino {
+	if r.board == nil {
+		r.dealtWithoutBoard = true
+	}
+	return tetris.TetrominoI
+}
+
+func TestGameSetsBoardToRandomizer(t *testing.T) {
+	first := &boardRandomizer{}
+	game := player.NewGame(tetris.NewBoard(), player.NewRandom(1), first, 2)
+	assert.Equal(t, game.Board(), first.board)
+	assert.False(t, first.dealtWithoutBoard)
+
+	second := &boardRandomizer{}
+	game.SetRandomizer(second)
+	assert.Nil(t, game.Play())
+	assert.Equal(t, game.Board(), second.board)
+	assert.False(t, second.dealtWithoutBoard)
+}
@@ -0,0 +1,77 @@
+// Package player contains the interface of the players of tetris - the AIs that decide where to place tetrominoes -
+// and the game that they play.
+package player
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Move is a player's decision where to place the current tetromino.
+// If Hold is true, the current tetromino is put in the hold slot and the tetromino taken out of it is placed instead.
+// If the hold slot is empty, the first tetromino of the queue is placed instead.
+type Move struct {
+	Hold      bool
+	Placement tetris.Placement
+}
+
+// Matches returns true if both moves hold or neither does and they lock the same tetromino in the same cells,
+// no matter how the piece was moved and rotated to get there.
+func (m Move) Matches(other Move) bool {
+	piece, otherPiece := m.Placement.Piece, other.Placement.Piece
+	return m.Hold == other.Hold && piece.Tetromino == otherPiece.Tetromino && piece.Cells() == otherPiece.Cells()
+}
+
+// Player decides where to place tetrominoes.
+type Player interface {
+	// Play returns the move for the current tetromino on the board, knowing the queue of the tetrominoes after it.
+	// The placement of the move must be one of the board's placements of the placed tetromino, see Move.
+	// Play must not change the board. Play returns error if there are no moves, which means the game is over.
+	Play(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) (Move, error)
+}
+
+// RandomizerObserver is implemented by players that take into account the randomizer that deals the tetrominoes,
+// e.g. to know how likely each of them is to come next, see tetris.NextProbabilities.
+// A game sets its randomizer to its player when the game is created and whenever the randomizer is changed.
+// The player must not call the randomizer's Next method.
+type RandomizerObserver interface {
+	SetRandomizer(randomizer tetris.Randomizer)
+}
+
+// Factory creates a player which draws its random numbers, if it needs any, from a source with the given seed.
+type Factory func(seed int64) Player
+
+// factories contains the registered factories of players by name.
+var factories = make(map[string]Factory)
+
+// Register makes a player available by the given name in New.
+// Register is meant to be called from the init function of the package that implements the player.
+// Register panics if a player with the same name is already registered.
+func Register(name string, factory Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Errorf("Register: player %q is already registered", name))
+	}
+	factories[name] = factory
+}
+
+// Names returns the sorted names of the registered players.
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the registered player with the given name, seeding its random numbers with the given seed.
+// New returns error if there is no player with that name.
+func New(name string, seed int64) (Player, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("New: unknown player %q", name)
+	}
+	return factory(seed), nil
+}
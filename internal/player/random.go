@@ -0,0 +1,38 @@
+package player
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// RandomName is the name of the random player, see NewRandom.
+const RandomName = "random"
+
+func init() {
+	Register(RandomName, func(seed int64) Player {
+		return NewRandom(seed)
+	})
+}
+
+// random is a player that places each tetromino at a random placement.
+type random struct {
+	rng *rand.Rand
+}
+
+// NewRandom creates a player that places each tetromino at a random placement and never holds.
+// It is the baseline which other players should beat.
+func NewRandom(seed int64) Player {
+	return &random{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Play implements Player.
+func (r *random) Play(board *tetris.Board, current tetris.Tetromino, queue []tetris.Tetromino) (Move, error) {
+	placements := board.Placements(current)
+	if len(placements) == 0 {
+		return Move{}, fmt.Errorf("random.Play: can not place tetromino %s", current)
+	}
+
+	return Move{Placement: placements[r.rng.Intn(len(placements))]}, nil
+}
@@ -2,66 +2,148 @@ package tetris
 
 import (
 	"fmt"
+	"math/bits"
 )
 
 // The default tetris board size.
 const (
-	defaultBoardWidth  = 10
-	defaultBoardHeight = 20
+	DefaultBoardWidth  = 10
+	DefaultBoardHeight = 20
 )
 
+// minBoardSize is the minimum width and height of a board.
+// Every rotation of every tetromino fits in a board of that size.
+const minBoardSize = 4
+
+// MaxBoardWidth is the maximum width of a board.
+// Each row of the board is stored as a bitmask in a uint32.
+const MaxBoardWidth = 32
+
+// MaxBoardHeight is the maximum height of a board.
+// Taller boards would not fit on the screen of the GUI, whose cells shrink with the height of the board.
+const MaxBoardHeight = 200
+
 // tetrominoMatrices is the slice of matrices for each rotation of each tetromino.
 // tetrominoMatrices is read-only, shared by all boards.
 var tetrominoMatrices [][]TetrominoMatrix
 
+// tetrominoMasks contains a bitmask for each row of each tetromino matrix.
+// Bit j of tetrominoMasks[tetromino][rotation][i] is set if tetrominoMatrices[tetromino][rotation][i][j] is true.
+// tetrominoMasks is read-only, shared by all boards.
+var tetrominoMasks [][][]uint32
+
 func init() {
 	tetrominoMatrices = TetrominoMatrices()
+
+	tetrominoMasks = make([][][]uint32, len(tetrominoMatrices))
+	for tetromino := range tetrominoMatrices {
+		tetrominoMasks[tetromino] = make([][]uint32, len(tetrominoMatrices[tetromino]))
+		for rotation, matrix := range tetrominoMatrices[tetromino] {
+			tetrominoMasks[tetromino][rotation] = matrixMasks(matrix)
+		}
+	}
+}
+
+// matrixMasks returns a bitmask for each row of the given matrix.
+func matrixMasks(matrix TetrominoMatrix) []uint32 {
+	masks := make([]uint32, len(matrix))
+	for i := range matrix {
+		for j := range matrix[i] {
+			if matrix[i][j] {
+				masks[i] |= 1 << uint(j)
+			}
+		}
+	}
+	return masks
 }
 
 // Board is a tetris board.
 // Board allows dropping tetrominoes and keeps statistics about the game so far and the current state.
-// The zero value of Board is not usable, NewBoard or NewBoardFromBoard should be used to create a Board.
+// The zero value of Board is not usable, NewBoard, NewBoardWithSize, NewBoardFromBoard or NewShapeFromBoard
+// should be used to create a Board.
 type Board struct {
 	width  int
 	height int
 
-	// cells contains the cells of the board.
-	// Cells are indexed from 0 from left to right and top to bottom.
-	cells [][]Tetromino
+	// rows contains a bitmask of the occupied cells of each row.
+	// Bit col of rows[row] is set if the cell (row, col) is occupied.
+	// Rows are indexed from 0 from top to bottom.
+	rows []uint32
+
+	// fullRow is the bitmask of a row in which every cell is occupied.
+	fullRow uint32
+
+	// colors contains the tetromino that occupies each cell, row by row.
+	// colors is only needed to visualize the board and is nil for boards created with NewShapeFromBoard.
+	colors []Tetromino
 
 	gameOver bool
 
+	// held is the tetromino in the hold slot.
+	// holdUsed is true if the hold slot has been used since the last tetromino was dropped.
+	held     Tetromino
+	holdUsed bool
+
+	// lastClear is the line clear that resulted from locking the last tetromino.
+	// combo is the number of line clears in a row so far and backToBack is true if the last one was difficult.
+	lastClear  LineClear
+	combo      int
+	backToBack bool
+
+	// landingHeight and erodedPieceCells describe where the last tetromino has been locked, see features.go.
+	landingHeight    float64
+	erodedPieceCells int
+
+	score              int
 	clearedLines       int
 	droppedTetrominoes int
 	heightsByColumn    []int
 	holesByColumn      []int
 }
 
-// NewBoard creates a new, empty Board.
+// NewBoard creates a new, empty Board of the default size.
 func NewBoard() *Board {
-	board := Board{
-		width:           defaultBoardWidth,
-		height:          defaultBoardHeight,
-		heightsByColumn: make([]int, defaultBoardWidth),
-		holesByColumn:   make([]int, defaultBoardWidth),
+	return NewBoardWithSize(DefaultBoardWidth, DefaultBoardHeight)
+}
+
+// ValidateBoardSize returns error if a board with the given number of columns and rows can not be created,
+// because it would be too small to fit every tetromino or larger than MaxBoardWidth and MaxBoardHeight.
+func ValidateBoardSize(width, height int) error {
+	if width < minBoardSize || height < minBoardSize || width > MaxBoardWidth || height > MaxBoardHeight {
+		return fmt.Errorf("invalid board size %dx%d, expected %d to %d columns and %d to %d rows",
+			width, height, minBoardSize, MaxBoardWidth, minBoardSize, MaxBoardHeight)
 	}
+	return nil
+}
 
-	board.cells = make([][]Tetromino, board.height)
-	for row := range board.cells {
-		board.cells[row] = make([]Tetromino, board.width)
+// NewBoardWithSize creates a new, empty Board with the given number of columns and rows.
+// NewBoardWithSize panics if the board size is invalid, see ValidateBoardSize.
+func NewBoardWithSize(width, height int) *Board {
+	if err := ValidateBoardSize(width, height); err != nil {
+		panic(fmt.Errorf("NewBoardWithSize: %s", err))
 	}
 
-	return &board
+	return &Board{
+		width:           width,
+		height:          height,
+		rows:            make([]uint32, height),
+		fullRow:         uint32(1<<uint(width) - 1),
+		colors:          make([]Tetromino, width*height),
+		heightsByColumn: make([]int, width),
+		holesByColumn:   make([]int, width),
+	}
 }
 
 // NewBoardFromBoard creates an independent copy of the given board.
 func NewBoardFromBoard(other *Board) *Board {
 	board := *other
 
-	board.cells = make([][]Tetromino, other.height)
-	for row := range board.cells {
-		board.cells[row] = make([]Tetromino, other.width)
-		copy(board.cells[row], other.cells[row])
+	board.rows = make([]uint32, other.height)
+	copy(board.rows, other.rows)
+
+	if other.colors != nil {
+		board.colors = make([]Tetromino, len(other.colors))
+		copy(board.colors, other.colors)
 	}
 
 	board.heightsByColumn = make([]int, other.width)
@@ -73,6 +155,55 @@ func NewBoardFromBoard(other *Board) *Board {
 	return &board
 }
 
+// NewShapeFromBoard creates an independent copy of the given board without the tetrominoes that occupy its cells.
+// The copy only knows which cells are occupied, which is all that is needed to keep playing on it,
+// and is cheaper to create and copy. Calling At on the copy panics, Occupied should be used instead.
+func NewShapeFromBoard(other *Board) *Board {
+	board := Board{
+		rows:            make([]uint32, other.height),
+		heightsByColumn: make([]int, other.width),
+		holesByColumn:   make([]int, other.width),
+	}
+	board.CopyFrom(other)
+
+	return &board
+}
+
+// CopyFrom makes the board an independent copy of the other board, reusing the board's memory.
+// The board keeps its kind: the tetrominoes occupying the cells are only copied if the board has been created
+// with them, otherwise only the shape of the other board is copied.
+// CopyFrom panics if the boards are of different sizes
+// or if the board keeps the tetrominoes occupying its cells, but the other board does not.
+func (b *Board) CopyFrom(other *Board) {
+	if b.colors != nil && other.colors == nil {
+		panic(fmt.Errorf("Board.CopyFrom: can not copy a board that only keeps its shape"))
+	}
+
+	if len(b.rows) != other.height || len(b.heightsByColumn) != other.width {
+		panic(fmt.Errorf(
+			"Board.CopyFrom: can not copy %dx%d board to %dx%d board",
+			other.width, other.height, len(b.heightsByColumn), len(b.rows),
+		))
+	}
+
+	rows, colors, heightsByColumn, holesByColumn := b.rows, b.colors, b.heightsByColumn, b.holesByColumn
+	*b = *other
+
+	b.rows = rows
+	copy(b.rows, other.rows)
+
+	b.colors = colors
+	if b.colors != nil {
+		copy(b.colors, other.colors)
+	}
+
+	b.heightsByColumn = heightsByColumn
+	copy(b.heightsByColumn, other.heightsByColumn)
+
+	b.holesByColumn = holesByColumn
+	copy(b.holesByColumn, other.holesByColumn)
+}
+
 // Width returns the width of the board.
 func (b *Board) Width() int {
 	return b.width
@@ -108,19 +239,67 @@ func (b *Board) HolesByColumn() []int {
 	return b.holesByColumn
 }
 
+// Held returns the tetromino in the hold slot of the board.
+// If the hold slot is empty, TetrominoEmpty is returned.
+func (b *Board) Held() Tetromino {
+	return b.held
+}
+
+// CanHold returns true if the hold slot can be used.
+// The hold slot can only be used once per dropped tetromino.
+func (b *Board) CanHold() bool {
+	return !b.holdUsed && !b.gameOver
+}
+
+// Hold swaps the given tetromino, which is the one about to be dropped, with the tetromino in the hold slot.
+// Hold returns the previously held tetromino, which should be dropped instead.
+// If the hold slot was empty, TetrominoEmpty is returned and the tetromino after the given one should be dropped instead.
+// Hold panics if the given tetromino is invalid or if the hold slot can not be used.
+func (b *Board) Hold(tetromino Tetromino) Tetromino {
+	if !tetromino.Valid() {
+		panic(fmt.Errorf("Board.Hold: invalid tetromino %d provided", tetromino))
+	}
+
+	if !b.CanHold() {
+		panic(fmt.Errorf("Board.Hold: can not hold: the hold slot has already been used or the game is over"))
+	}
+
+	held := b.held
+	b.held = tetromino
+	b.holdUsed = true
+
+	return held
+}
+
 // At returns the tetromino at the given position of the board.
 // If the given position is not occupied, TetrominoEmpty is returned.
 // The cells of the board are indexed from 0, left to right and top to bottom.
-// At panics if invalid coordinates are provided.
+// At panics if invalid coordinates are provided or if the board has been created with NewShapeFromBoard.
 func (b *Board) At(row, col int) Tetromino {
 	if !b.isValidCell(row, col) {
 		panic(fmt.Errorf("Board.At: invalid coordinates (%d, %d) provided", row, col))
 	}
 
-	return b.cells[row][col]
+	if b.colors == nil {
+		panic(fmt.Errorf("Board.At: the board only keeps its shape, Board.Occupied should be used"))
+	}
+
+	return b.colors[row*b.width+col]
+}
+
+// Occupied returns true if the given position of the board is occupied by some tetromino.
+// The cells of the board are indexed from 0, left to right and top to bottom.
+// Occupied panics if invalid coordinates are provided.
+func (b *Board) Occupied(row, col int) bool {
+	if !b.isValidCell(row, col) {
+		panic(fmt.Errorf("Board.Occupied: invalid coordinates (%d, %d) provided", row, col))
+	}
+
+	return b.rows[row]&(1<<uint(col)) != 0
 }
 
 // Drop drops a specified rotation of a tetromino such that the leftmost cell is in the given column.
+// The tetromino is hard dropped from the top of the board and the points for hard dropping it are awarded.
 // Drop returns error if tetromino is dropped, but that leads to game over.
 // Drop panics if the given tetromino, rotation or column are invalid or if the board's game is already over.
 func (b *Board) Drop(tetromino Tetromino, rotation int, column int) error {
@@ -139,88 +318,107 @@ func (b *Board) Drop(tetromino Tetromino, rotation int, column int) error {
 		panic(fmt.Errorf("Board.Drop: can not drop: game is over"))
 	}
 
-	tetrominoMatrix := tetrominoMatrices[tetromino][rotation]
+	masks := tetrominoMasks[tetromino][rotation]
 
-	if column+len(tetrominoMatrix[0]) > b.width {
+	if column < 0 || column+len(tetrominoMatrices[tetromino][rotation][0]) > b.width {
 		panic(fmt.Errorf(
 			"Board.Drop: can not drop: invalid column %d provided for tetromino %s, rotation %d",
 			column, tetromino, rotation,
 		))
 	}
 
-	if !b.canBePut(tetrominoMatrix, 0, column) {
+	if !b.canBePut(masks, 0, column) {
 		b.gameOver = true
-		for i := range tetrominoMatrix {
-			for j := range tetrominoMatrix[i] {
-				if tetrominoMatrix[i][j] {
-					b.cells[i][column+j] = tetromino
-				}
-			}
-		}
+		b.put(tetromino, masks, 0, column)
 		return fmt.Errorf("Board.Drop: the game just ended.")
 	}
 
-	var row int
-	for row = range b.cells {
-		if !b.canBePut(tetrominoMatrix, row, column) {
-			row--
-			break
-		}
+	row := 0
+	for b.canBePut(masks, row+1, column) {
+		row++
 	}
 
-	for i := range tetrominoMatrix {
-		for j := range tetrominoMatrix[i] {
-			if tetrominoMatrix[i][j] {
-				b.cells[row+i][column+j] = tetromino
-			}
-		}
+	b.put(tetromino, masks, row, column)
+	b.score += hardDropPoints * row
+
+	var pieceRows [4]uint32
+	for i, mask := range masks {
+		pieceRows[i] = mask << uint(column)
 	}
+	b.locked(TSpinNone, row, pieceRows[:len(masks)])
+
+	return nil
+}
+
+// locked updates the board after a tetromino has been locked in place:
+// it clears any full rows, scores them and recalculates the statistics.
+// pieceRows contains the bitmasks of the tetromino's cells in each row it occupies, starting from the row top.
+func (b *Board) locked(spin TSpin, top int, pieceRows []uint32) {
+	level := b.Level()
 
 	b.droppedTetrominoes++
-	rowsCleared := b.clearFullRows()
-
-	// Statistics will only be recalculated for columns [fromCol; toCol).
-	fromCol := 0
-	toCol := b.width
-	if rowsCleared == 0 {
-		fromCol = column
-		toCol = column + len(tetrominoMatrix[0])
-	}
+	b.holdUsed = false
+	b.landingHeight, b.erodedPieceCells = b.landing(top, pieceRows)
+	b.lastClear = b.lineClear(b.clearFullRows(), spin)
+	b.scoreLineClear(b.lastClear, level)
+	b.updateStatistics()
+}
 
-	for col := fromCol; col < toCol; col++ {
-		if col >= b.width {
+// put occupies the cells of the given tetromino masks with their top left cell at coordinates (row, col).
+// Cells that stick out of the bottom of the board are ignored.
+func (b *Board) put(tetromino Tetromino, masks []uint32, row, col int) {
+	for i, mask := range masks {
+		if row+i >= b.height {
 			break
 		}
 
-		b.heightsByColumn[col] = 0
-		b.holesByColumn[col] = 0
+		b.rows[row+i] |= mask << uint(col)
 
-		inHole := false
-		for row := range b.cells {
-			if inHole && b.cells[row][col] == TetrominoEmpty {
-				b.holesByColumn[col]++
-			}
-			if !inHole && b.cells[row][col] != TetrominoEmpty {
-				inHole = true
-				b.heightsByColumn[col] = b.height - row
+		if b.colors == nil {
+			continue
+		}
+		for j := 0; mask != 0; j, mask = j+1, mask>>1 {
+			if mask&1 != 0 {
+				b.colors[(row+i)*b.width+col+j] = tetromino
 			}
 		}
 	}
+}
 
-	return nil
+// updateStatistics recalculates the heights and holes of all columns.
+func (b *Board) updateStatistics() {
+	for col := range b.heightsByColumn {
+		b.heightsByColumn[col] = 0
+		b.holesByColumn[col] = 0
+	}
+
+	// above contains the columns which have an occupied cell above the current row.
+	var above uint32
+	for row, mask := range b.rows {
+		for holes := above &^ mask; holes != 0; holes &= holes - 1 {
+			b.holesByColumn[bits.TrailingZeros32(holes)]++
+		}
+
+		for tops := mask &^ above; tops != 0; tops &= tops - 1 {
+			b.heightsByColumn[bits.TrailingZeros32(tops)] = b.height - row
+		}
+
+		above |= mask
+	}
 }
 
-// canBePut returns true if the given tetromino matrix can be put on the board
-// with its top left cell at coordinates (row, col).
-// If the matrix sticks out of the board or overlaps a non-empty cell on the board, false is returned.
-func (b *Board) canBePut(tetrominoMatrix TetrominoMatrix, row, col int) bool {
-	for i := range tetrominoMatrix {
-		for j := range tetrominoMatrix[i] {
-			if tetrominoMatrix[i][j] {
-				if !b.isValidCell(row+i, col+j) || b.cells[row+i][col+j] != TetrominoEmpty {
-					return false
-				}
-			}
+// canBePut returns true if the given tetromino masks can be put on the board
+// with their top left cell at coordinates (row, col).
+// If the masks stick out of the bottom of the board or overlap a non-empty cell on the board, false is returned.
+// The column is expected to be valid for the masks.
+func (b *Board) canBePut(masks []uint32, row, col int) bool {
+	if row < 0 || row+len(masks) > b.height {
+		return false
+	}
+
+	for i, mask := range masks {
+		if b.rows[row+i]&(mask<<uint(col)) != 0 {
+			return false
 		}
 	}
 	return true
@@ -234,12 +432,7 @@ func (b *Board) isValidCell(row, col int) bool {
 
 // isFullRow returns true if all of the cells in the given row of the board are non-empty.
 func (b *Board) isFullRow(row int) bool {
-	for _, cell := range b.cells[row] {
-		if cell == TetrominoEmpty {
-			return false
-		}
-	}
-	return true
+	return b.rows[row] == b.fullRow
 }
 
 // clearFullRows traverses the board and clears any full rows.
@@ -254,7 +447,12 @@ func (b *Board) clearFullRows() int {
 	for idxTo >= 0 {
 		if idxFrom < 0 {
 			// Insert a new empty row.
-			b.cells[idxTo] = make([]Tetromino, b.width)
+			b.rows[idxTo] = 0
+			if b.colors != nil {
+				for col, cells := 0, b.colorsRow(idxTo); col < b.width; col++ {
+					cells[col] = TetrominoEmpty
+				}
+			}
 			idxTo--
 			continue
 		}
@@ -268,10 +466,42 @@ func (b *Board) clearFullRows() int {
 		}
 
 		// Non-full rows are just moved down.
-		b.cells[idxTo] = b.cells[idxFrom]
+		if idxFrom != idxTo {
+			b.rows[idxTo] = b.rows[idxFrom]
+			if b.colors != nil {
+				copy(b.colorsRow(idxTo), b.colorsRow(idxFrom))
+			}
+		}
 		idxFrom--
 		idxTo--
 	}
 
 	return rowsCleared
 }
+
+// colorsRow returns the tetrominoes occupying the cells of the given row of the board.
+func (b *Board) colorsRow(row int) []Tetromino {
+	return b.colors[row*b.width : (row+1)*b.width]
+}
+
+// Hash returns a hash of the board's shape: which of its cells are occupied.
+// Boards with the same shape have the same hash and boards with different shapes very likely have different ones.
+// Like Zobrist hashing, the hash is the XOR of random-like keys, one for each row and its occupied cells,
+// so that it is cheap to compute from the bitmasks of the rows.
+func (b *Board) Hash() uint64 {
+	var hash uint64
+	for row, mask := range b.rows {
+		if mask != 0 {
+			hash ^= mix64(uint64(row)<<32 | uint64(mask))
+		}
+	}
+	return hash
+}
+
+// mix64 returns a random-like 64-bit number for the given one, using the finalizer of SplitMix64.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
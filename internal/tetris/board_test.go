@@ -43,6 +43,35 @@ func TestBoardSize(t *testing.T) {
 	assert.Equal(t, 20, board.Height())
 }
 
+func TestNewBoardWithSize(t *testing.T) {
+	board := tetris.NewBoardWithSize(6, 40)
+	assert.Equal(t, 6, board.Width())
+	assert.Equal(t, 40, board.Height())
+	assert.Len(t, board.HeightsByColumn(), 6)
+	assert.Len(t, board.HolesByColumn(), 6)
+}
+
+func TestNewBoardWithSizePanicsOnTooSmallBoard(t *testing.T) {
+	assert.Panics(t, func() { tetris.NewBoardWithSize(3, 20) })
+	assert.Panics(t, func() { tetris.NewBoardWithSize(10, 3) })
+	assert.NotPanics(t, func() { tetris.NewBoardWithSize(4, 4) })
+}
+
+func TestBoardDropOnNarrowAndTallBoard(t *testing.T) {
+	board := tetris.NewBoardWithSize(4, 40)
+
+	assert.Nil(t, board.Drop(I, 1, 0))
+	assert.Equal(t, 1, board.ClearedLines())
+
+	assert.Nil(t, board.Drop(O, 0, 2))
+	assert.Nil(t, board.Drop(I, 0, 0))
+	assert.Equal(t, I, board.At(36, 0))
+	assert.Equal(t, O, board.At(38, 3))
+	assert.Equal(t, []int{4, 0, 2, 2}, board.HeightsByColumn())
+
+	assert.Panics(t, func() { board.Drop(T, 0, 2) })
+}
+
 func TestNewBoardFromBoardDoesNotShareCellsWithOriginal(t *testing.T) {
 	original := tetris.NewBoard()
 	original.Drop(O, 0, 0)
@@ -58,6 +87,53 @@ func TestNewBoardFromBoardDoesNotShareCellsWithOriginal(t *testing.T) {
 	assert.Equal(t, copy.At(16, 1), Empty)
 }
 
+func TestNewShapeFromBoardOnlyKeepsShape(t *testing.T) {
+	original := tetris.NewBoard()
+	original.Drop(T, 0, 0)
+
+	shape := tetris.NewShapeFromBoard(original)
+
+	assert.True(t, shape.Occupied(18, 0))
+	assert.True(t, shape.Occupied(19, 1))
+	assert.False(t, shape.Occupied(19, 0))
+	assert.Equal(t, original.HeightsByColumn(), shape.HeightsByColumn())
+	assert.Equal(t, original.HolesByColumn(), shape.HolesByColumn())
+	assert.Panics(t, func() { shape.At(18, 0) })
+
+	assert.Nil(t, shape.Drop(O, 0, 0))
+	assert.True(t, shape.Occupied(16, 0))
+	assert.False(t, original.Occupied(16, 0))
+}
+
+func TestBoardCopyFrom(t *testing.T) {
+	original := tetris.NewBoard()
+	original.Drop(I, 1, 0)
+	original.Drop(I, 1, 4)
+
+	copy := tetris.NewBoard()
+	copy.Drop(O, 0, 8)
+	copy.CopyFrom(original)
+
+	assert.Equal(t, I, copy.At(19, 0))
+	assert.Equal(t, Empty, copy.At(19, 8))
+	assert.Equal(t, 2, copy.DroppedTetrominoes())
+
+	copy.Drop(O, 0, 8)
+
+	assert.Equal(t, 0, original.ClearedLines())
+	assert.Equal(t, Empty, original.At(19, 8))
+	assert.Equal(t, 1, copy.ClearedLines())
+	assert.Equal(t, O, copy.At(19, 8))
+}
+
+func TestBoardCopyFromPanics(t *testing.T) {
+	board := tetris.NewBoard()
+
+	assert.Panics(t, func() { board.CopyFrom(tetris.NewBoardWithSize(8, 20)) })
+	assert.Panics(t, func() { board.CopyFrom(tetris.NewShapeFromBoard(board)) })
+	assert.NotPanics(t, func() { tetris.NewShapeFromBoard(board).CopyFrom(board) })
+}
+
 func TestBoardDropPanicsOnInvalidTetromino(t *testing.T) {
 	board := tetris.NewBoard()
 
@@ -187,6 +263,33 @@ func TestBoardDropClearsMultipleLines(t *testing.T) {
 	}
 }
 
+func TestBoardHold(t *testing.T) {
+	board := tetris.NewBoard()
+	assert.Equal(t, Empty, board.Held())
+	assert.True(t, board.CanHold())
+
+	assert.Equal(t, Empty, board.Hold(T))
+	assert.Equal(t, T, board.Held())
+	assert.False(t, board.CanHold())
+	assert.Panics(t, func() { board.Hold(I) })
+
+	board.Drop(I, 0, 0)
+	assert.True(t, board.CanHold())
+	assert.Equal(t, T, board.Hold(L))
+	assert.Equal(t, L, board.Held())
+
+	copy := tetris.NewBoardFromBoard(board)
+	assert.Equal(t, L, copy.Held())
+	assert.False(t, copy.CanHold())
+}
+
+func TestBoardHoldPanicsOnInvalidTetromino(t *testing.T) {
+	board := tetris.NewBoard()
+	assert.Panics(t, func() { board.Hold(Empty) })
+	assert.Panics(t, func() { board.Hold(tetris.Tetromino(8)) })
+	assert.True(t, board.CanHold())
+}
+
 func TestBoardAtPanicsOnInvalidCoordinates(t *testing.T) {
 	board := tetris.NewBoard()
 
@@ -216,6 +319,14 @@ func TestBoardAtPanicsOnInvalidCoordinates(t *testing.T) {
 	}
 }
 
+func TestBoardOccupiedPanicsOnInvalidCoordinates(t *testing.T) {
+	board := tetris.NewBoard()
+
+	assert.Panics(t, func() { board.Occupied(20, 0) })
+	assert.Panics(t, func() { board.Occupied(0, -1) })
+	assert.NotPanics(t, func() { board.Occupied(19, 9) })
+}
+
 func TestBoardAtNumbersCellsFromTopLeft(t *testing.T) {
 	board := tetris.NewBoard()
 	board.Drop(I, 0, 4)
@@ -237,3 +348,60 @@ func TestBoardAtNumbersCellsFromTopLeft(t *testing.T) {
 	assert.Equal(t, Empty, board.At(14, 4))
 	assert.Equal(t, Empty, board.At(15, 6))
 }
+
+func TestBoardDropOnWidestBoard(t *testing.T) {
+	board := tetris.NewBoardWithSize(tetris.MaxBoardWidth, 20)
+	for col := 0; col < tetris.MaxBoardWidth; col += 4 {
+		assert.Nil(t, board.Drop(I, 1, col))
+	}
+
+	assert.Equal(t, 1, board.ClearedLines())
+	assert.Panics(t, func() { tetris.NewBoardWithSize(tetris.MaxBoardWidth+1, 20) })
+}
+
+func TestValidateBoardSize(t *testing.T) {
+	assert.Nil(t, tetris.ValidateBoardSize(4, 4))
+	assert.Nil(t, tetris.ValidateBoardSize(tetris.MaxBoardWidth, tetris.MaxBoardHeight))
+	assert.NotNil(t, tetris.ValidateBoardSize(3, 20))
+	assert.NotNil(t, tetris.ValidateBoardSize(10, 3))
+	assert.NotNil(t, tetris.ValidateBoardSize(tetris.MaxBoardWidth+1, 20))
+	assert.NotNil(t, tetris.ValidateBoardSize(10, tetris.MaxBoardHeight+1))
+}
+
+func BenchmarkBoardCopyFromAndDrop(b *testing.B) {
+	original := tetris.NewBoard()
+	original.Drop(T, 0, 0)
+	original.Drop(L, 1, 4)
+
+	board := tetris.NewShapeFromBoard(original)
+	for i := 0; i < b.N; i++ {
+		board.CopyFrom(original)
+		board.Drop(S, 0, i%8)
+	}
+}
+
+func TestBoardHash(t *testing.T) {
+	board := tetris.NewBoard()
+	empty := board.Hash()
+
+	board.Drop(O, 0, 0)
+	assert.NotEqual(t, empty, board.Hash())
+
+	// The hash only depends on the shape of the board, not on the tetrominoes or the history.
+	other := tetris.NewBoard()
+	other.Drop(I, 1, 0)
+	other.Drop(I, 1, 4)
+	other.Drop(L, 0, 2)
+	other.Drop(O, 0, 6)
+	other.Drop(O, 0, 8)
+	assert.Equal(t, other.Hash(), tetris.NewShapeFromBoard(other).Hash())
+
+	cleared := tetris.NewBoardWithSize(4, 8)
+	cleared.Lock(tetris.Piece{Tetromino: I, Row: 6, Col: 0})
+	assert.Equal(t, 1, cleared.ClearedLines())
+	assert.Equal(t, tetris.NewBoardWithSize(4, 8).Hash(), cleared.Hash())
+
+	moved := tetris.NewBoard()
+	moved.Drop(O, 0, 2)
+	assert.NotEqual(t, moved.Hash(), board.Hash())
+}
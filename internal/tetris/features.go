@@ -0,0 +1,156 @@
+package tetris
+
+import "math/bits"
+
+// This file contains the features of boards used by the evaluation functions of
+// Pierre Dellacherie's and El-Tetris's tetris AIs, see https://imake.ninja/el-tetris-an-improvement-on-pierre-dellacheries-algorithm/.
+// Unlike the heights and holes of the columns, they are computed on demand.
+
+// landing returns the landing height and the number of eroded cells of a tetromino
+// that has just been locked, see LandingHeight and ErodedPieceCells.
+// pieceRows contains the bitmasks of the tetromino's cells in each row it occupies, starting from the row top.
+// The full rows are expected to not be cleared yet.
+func (b *Board) landing(top int, pieceRows []uint32) (float64, int) {
+	var (
+		bottom      = top + len(pieceRows) - 1
+		fullRows    int
+		erodedCells int
+	)
+	for i, mask := range pieceRows {
+		if b.rows[top+i] == b.fullRow {
+			fullRows++
+			erodedCells += bits.OnesCount32(mask)
+		}
+	}
+
+	return float64(2*b.height-top-bottom) / 2, fullRows * erodedCells
+}
+
+// LandingHeight returns the height at which the last tetromino has been locked: the average of the heights
+// of its top and bottom rows, where the bottom row of the board has height 1.
+func (b *Board) LandingHeight() float64 {
+	return b.landingHeight
+}
+
+// ErodedPieceCells returns the number of rows cleared by the last tetromino
+// multiplied by the number of its cells that have been cleared with them.
+func (b *Board) ErodedPieceCells() int {
+	return b.erodedPieceCells
+}
+
+// RowTransitions returns the number of horizontally neighbouring cells of which one is empty and the other is not.
+// The walls count as occupied cells, so each empty row has two transitions.
+func (b *Board) RowTransitions() int {
+	var transitions int
+	for _, mask := range b.rows {
+		walled := b.walled(mask)
+		transitions += bits.OnesCount64((walled ^ walled>>1) & (uint64(b.fullRow)<<1 | 1))
+	}
+	return transitions
+}
+
+// ColumnTransitions returns the number of vertically neighbouring cells of which one is empty and the other is not.
+// The floor counts as occupied cells and the space above the board as empty ones.
+func (b *Board) ColumnTransitions() int {
+	var (
+		transitions int
+		above       uint32
+	)
+	for _, mask := range b.rows {
+		transitions += bits.OnesCount32(mask ^ above)
+		above = mask
+	}
+	return transitions + bits.OnesCount32(b.fullRow&^above)
+}
+
+// CumulativeWells returns the sum of the depths of the board's wells, where each cell
+// of a well counts as deep as the number of its cells down to it, e.g. a well of depth 3 counts as 1+2+3.
+// A well is a vertical sequence of empty cells above the top of their column
+// with occupied cells (or walls) on their left and right.
+func (b *Board) CumulativeWells() int {
+	var (
+		sum     int
+		above   uint32
+		depths  [MaxBoardWidth]int
+		inWells uint32
+	)
+	for _, mask := range b.rows {
+		walled := b.walled(mask)
+		wells := uint32(^walled>>1&walled&(walled>>2)) & b.fullRow &^ above
+
+		for cols := inWells &^ wells; cols != 0; cols &= cols - 1 {
+			depths[bits.TrailingZeros32(cols)] = 0
+		}
+		for cols := wells; cols != 0; cols &= cols - 1 {
+			col := bits.TrailingZeros32(cols)
+			depths[col]++
+			sum += depths[col]
+		}
+
+		inWells = wells
+		above |= mask
+	}
+	return sum
+}
+
+// MaxWellDepth returns the depth of the deepest well of the board: how many rows its lowest column
+// is below the lower of its neighbouring columns. The walls are as high as the board.
+func (b *Board) MaxWellDepth() int {
+	var deepest int
+	for col, height := range b.heightsByColumn {
+		// The well is as deep as its lower neighbouring column, which is a wall at the edges.
+		lower := b.height
+		if col > 0 {
+			lower = b.heightsByColumn[col-1]
+		}
+		if col < b.width-1 && b.heightsByColumn[col+1] < lower {
+			lower = b.heightsByColumn[col+1]
+		}
+
+		if depth := lower - height; depth > deepest {
+			deepest = depth
+		}
+	}
+	return deepest
+}
+
+// HoleDepth returns the sum of the number of occupied cells above each hole of the board in its column.
+func (b *Board) HoleDepth() int {
+	var (
+		depth    int
+		above    uint32
+		occupied [MaxBoardWidth]int
+	)
+	for _, mask := range b.rows {
+		for holes := above &^ mask; holes != 0; holes &= holes - 1 {
+			depth += occupied[bits.TrailingZeros32(holes)]
+		}
+		for cols := mask; cols != 0; cols &= cols - 1 {
+			occupied[bits.TrailingZeros32(cols)]++
+		}
+		above |= mask
+	}
+	return depth
+}
+
+// RowsWithHoles returns the number of rows that contain at least one hole.
+func (b *Board) RowsWithHoles() int {
+	var (
+		rows  int
+		above uint32
+	)
+	for _, mask := range b.rows {
+		if above&^mask != 0 {
+			rows++
+		}
+		above |= mask
+	}
+	return rows
+}
+
+// walled returns the given row bitmask shifted left by one column, with occupied cells for the walls on both sides.
+// Bit col+1 of the result is set if the cell (row, col) is occupied, bits 0 and width+1 are the walls.
+// The result has 64 bits, as the walls of the widest boards do not fit in 32 bits.
+func (b *Board) walled(mask uint32) uint64 {
+	return uint64(mask)<<1 | 1 | (uint64(b.fullRow)+1)<<1
+}
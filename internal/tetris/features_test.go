@@ -0,0 +1,72 @@
+package tetris_test
+
+import (
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardFeatures(t *testing.T) {
+	// The bottom rows of the board are:
+	//   XXXX.
+	//   XX...
+	//   XX...
+	board := tetris.NewBoardWithSize(5, 8)
+	board.Lock(tetris.Piece{Tetromino: O, Row: 6, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: I, Row: 4, Col: 0})
+
+	assert.Equal(t, 3.0, board.LandingHeight())
+	assert.Equal(t, 0, board.ErodedPieceCells())
+	assert.Equal(t, 5*2+3*2, board.RowTransitions())
+	assert.Equal(t, 4+2+3, board.ColumnTransitions())
+	assert.Equal(t, 1, board.CumulativeWells())
+	assert.Equal(t, 3, board.MaxWellDepth())
+	assert.Equal(t, 4, board.HoleDepth())
+	assert.Equal(t, 2, board.RowsWithHoles())
+
+	// A vertical I in the last column clears the top row of the three.
+	board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 4, Col: 2})
+
+	assert.Equal(t, 1, board.ClearedLines())
+	assert.Equal(t, 2.5, board.LandingHeight())
+	assert.Equal(t, 1*1, board.ErodedPieceCells())
+}
+
+func TestBoardCumulativeWells(t *testing.T) {
+	// The vertical I pieces in the second and fourth columns leave three wells of depth 4 between them and the walls:
+	//   .X.X.
+	//   .X.X.
+	//   .X.X.
+	//   .X.X.
+	board := tetris.NewBoardWithSize(5, 8)
+	board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 4, Col: -1})
+	board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 4, Col: 1})
+
+	assert.Equal(t, []int{0, 4, 0, 4, 0}, board.HeightsByColumn())
+	assert.Equal(t, 3*(1+2+3+4), board.CumulativeWells())
+	assert.Equal(t, 4, board.MaxWellDepth())
+}
+
+func TestBoardDropLandingHeightAndErodedPieceCells(t *testing.T) {
+	board := tetris.NewBoardWithSize(4, 8)
+
+	board.Drop(O, 0, 0)
+	assert.Equal(t, 1.5, board.LandingHeight())
+	assert.Equal(t, 0, board.ErodedPieceCells())
+
+	board.Drop(O, 0, 2)
+	assert.Equal(t, 1.5, board.LandingHeight())
+	assert.Equal(t, 2*4, board.ErodedPieceCells())
+}
+
+func TestBoardFeaturesOnEmptyWidestBoard(t *testing.T) {
+	board := tetris.NewBoardWithSize(tetris.MaxBoardWidth, 20)
+
+	assert.Equal(t, 2*20, board.RowTransitions())
+	assert.Equal(t, tetris.MaxBoardWidth, board.ColumnTransitions())
+	assert.Equal(t, 0, board.CumulativeWells())
+	assert.Equal(t, 0, board.MaxWellDepth())
+	assert.Equal(t, 0, board.HoleDepth())
+	assert.Equal(t, 0, board.RowsWithHoles())
+}
@@ -0,0 +1,451 @@
+package tetris
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Orientation is one of the four orientations of a piece in the Super Rotation System (SRS).
+// See https://tetris.wiki/Super_Rotation_System.
+type Orientation int
+
+// The orientations of a piece - the one it spawns in
+// and the ones reached by rotating it clockwise once, twice and three times.
+const (
+	OrientationSpawn Orientation = iota
+	OrientationRight
+	OrientationTwo
+	OrientationLeft
+)
+
+// orientationsCount is the number of orientations of each piece.
+const orientationsCount = 4
+
+// Orientation implements Stringer.
+func (o Orientation) String() string {
+	switch o {
+	case OrientationSpawn:
+		return "0"
+	case OrientationRight:
+		return "R"
+	case OrientationTwo:
+		return "2"
+	case OrientationLeft:
+		return "L"
+	default:
+		panic(fmt.Errorf("Orientation.String: invalid orientation %d provided", o))
+	}
+}
+
+// Turn is a rotation of a piece - clockwise, counter-clockwise or by 180 degrees.
+type Turn int
+
+// The possible turns of a piece.
+const (
+	TurnClockwise Turn = iota
+	TurnCounterClockwise
+	Turn180
+)
+
+// Turn implements Stringer.
+func (t Turn) String() string {
+	switch t {
+	case TurnClockwise:
+		return "CW"
+	case TurnCounterClockwise:
+		return "CCW"
+	case Turn180:
+		return "180"
+	default:
+		panic(fmt.Errorf("Turn.String: invalid turn %d provided", t))
+	}
+}
+
+// apply returns the orientation reached by turning a piece with the given orientation.
+func (t Turn) apply(o Orientation) Orientation {
+	switch t {
+	case TurnClockwise:
+		return (o + 1) % orientationsCount
+	case TurnCounterClockwise:
+		return (o + orientationsCount - 1) % orientationsCount
+	case Turn180:
+		return (o + 2) % orientationsCount
+	default:
+		panic(fmt.Errorf("Turn.apply: invalid turn %d provided", t))
+	}
+}
+
+// Cell is the position of a cell on a board.
+// Cells are indexed from 0, left to right and top to bottom.
+type Cell struct {
+	Row int
+	Col int
+}
+
+// Piece is a tetromino that is moved on a board until it is locked in place.
+// Unlike Board.Drop, which only drops a tetromino straight down, pieces can be shifted, rotated and soft dropped,
+// so they can reach positions under overhangs.
+// Pieces are rotated according to the Super Rotation System, including its wall kicks.
+// Row and Col are the coordinates of the top left cell of the piece's bounding box.
+// The bounding box may stick out of the board as long as the piece's cells do not.
+type Piece struct {
+	Tetromino   Tetromino
+	Orientation Orientation
+	Row         int
+	Col         int
+
+	// spin is the T-spin performed by the last move of the piece, if it was a rotation.
+	spin TSpin
+}
+
+// TSpin returns the kind of T-spin the piece would be locked with in its current position.
+// Only pieces whose last move was a rotation, see Board.Rotate, can be locked with a T-spin.
+func (p Piece) TSpin() TSpin {
+	return p.spin
+}
+
+// Cells returns the cells of the board that are occupied by the piece,
+// sorted from top to bottom and from left to right.
+func (p Piece) Cells() [4]Cell {
+	cells := pieceCells[p.Tetromino][p.Orientation]
+	for i := range cells {
+		cells[i].Row += p.Row
+		cells[i].Col += p.Col
+	}
+	return cells
+}
+
+// moved returns a copy of the piece moved by the given number of rows and columns.
+// Moving a piece cancels its T-spin.
+func (p Piece) moved(rows, cols int) Piece {
+	p.Row += rows
+	p.Col += cols
+	p.spin = TSpinNone
+	return p
+}
+
+// pieceBoxSizes is the size of the bounding box of each tetromino in the Super Rotation System.
+var pieceBoxSizes = []int{
+	TetrominoEmpty: 0,
+	TetrominoI:     4,
+	TetrominoJ:     3,
+	TetrominoL:     3,
+	TetrominoO:     2,
+	TetrominoS:     3,
+	TetrominoT:     3,
+	TetrominoZ:     3,
+}
+
+// pieceCells contains the cells occupied by each tetromino in each orientation,
+// relative to the top left cell of its bounding box.
+// pieceCells is read-only, shared by all pieces.
+var pieceCells [][orientationsCount][4]Cell
+
+func init() {
+	spawnCells := [][4]Cell{
+		TetrominoEmpty: {},
+		TetrominoI:     {{1, 0}, {1, 1}, {1, 2}, {1, 3}},
+		TetrominoJ:     {{0, 0}, {1, 0}, {1, 1}, {1, 2}},
+		TetrominoL:     {{0, 2}, {1, 0}, {1, 1}, {1, 2}},
+		TetrominoO:     {{0, 0}, {0, 1}, {1, 0}, {1, 1}},
+		TetrominoS:     {{0, 1}, {0, 2}, {1, 0}, {1, 1}},
+		TetrominoT:     {{0, 1}, {1, 0}, {1, 1}, {1, 2}},
+		TetrominoZ:     {{0, 0}, {0, 1}, {1, 1}, {1, 2}},
+	}
+
+	pieceCells = make([][orientationsCount][4]Cell, len(spawnCells))
+	for tetromino := range spawnCells {
+		size := pieceBoxSizes[tetromino]
+		cells := spawnCells[tetromino]
+		for orientation := range pieceCells[tetromino] {
+			// Cells are kept sorted from top to bottom and from left to right.
+			sort.Slice(cells[:], func(i, j int) bool {
+				if cells[i].Row != cells[j].Row {
+					return cells[i].Row < cells[j].Row
+				}
+				return cells[i].Col < cells[j].Col
+			})
+			pieceCells[tetromino][orientation] = cells
+
+			// Rotate the cells clockwise inside the bounding box.
+			for i := range cells {
+				cells[i] = Cell{Row: cells[i].Col, Col: size - 1 - cells[i].Row}
+			}
+		}
+	}
+}
+
+// pieceMasks contains for each tetromino and orientation a bitmask for each row of its bounding box.
+// Bit j of pieceMasks[tetromino][orientation][i] is set if the cell in row i and column j of the box is occupied.
+// pieceMasks is read-only, shared by all pieces.
+var pieceMasks [][orientationsCount][4]uint32
+
+func init() {
+	pieceMasks = make([][orientationsCount][4]uint32, len(pieceCells))
+	for tetromino := range pieceCells {
+		for orientation, cells := range pieceCells[tetromino] {
+			if tetromino == int(TetrominoEmpty) {
+				continue
+			}
+			for _, cell := range cells {
+				pieceMasks[tetromino][orientation][cell.Row] |= 1 << uint(cell.Col)
+			}
+		}
+	}
+}
+
+// kick is an offset by which a piece is moved when it is rotated.
+// Unlike the kick tables on https://tetris.wiki/Super_Rotation_System, rows grow downwards.
+type kick struct {
+	rows int
+	cols int
+}
+
+// kicksJLSTZ and kicksI are the wall kicks tried in order when a piece is rotated by 90 degrees.
+// They are indexed by the orientation before and after the rotation.
+// The O tetromino is never kicked.
+var (
+	kicksJLSTZ = [orientationsCount][orientationsCount][]kick{
+		OrientationSpawn: {
+			OrientationRight: {{0, 0}, {0, -1}, {-1, -1}, {2, 0}, {2, -1}},
+			OrientationLeft:  {{0, 0}, {0, 1}, {-1, 1}, {2, 0}, {2, 1}},
+		},
+		OrientationRight: {
+			OrientationSpawn: {{0, 0}, {0, 1}, {1, 1}, {-2, 0}, {-2, 1}},
+			OrientationTwo:   {{0, 0}, {0, 1}, {1, 1}, {-2, 0}, {-2, 1}},
+		},
+		OrientationTwo: {
+			OrientationRight: {{0, 0}, {0, -1}, {-1, -1}, {2, 0}, {2, -1}},
+			OrientationLeft:  {{0, 0}, {0, 1}, {-1, 1}, {2, 0}, {2, 1}},
+		},
+		OrientationLeft: {
+			OrientationTwo:   {{0, 0}, {0, -1}, {1, -1}, {-2, 0}, {-2, -1}},
+			OrientationSpawn: {{0, 0}, {0, -1}, {1, -1}, {-2, 0}, {-2, -1}},
+		},
+	}
+
+	kicksI = [orientationsCount][orientationsCount][]kick{
+		OrientationSpawn: {
+			OrientationRight: {{0, 0}, {0, -2}, {0, 1}, {1, -2}, {-2, 1}},
+			OrientationLeft:  {{0, 0}, {0, -1}, {0, 2}, {-2, -1}, {1, 2}},
+		},
+		OrientationRight: {
+			OrientationSpawn: {{0, 0}, {0, 2}, {0, -1}, {-1, 2}, {2, -1}},
+			OrientationTwo:   {{0, 0}, {0, -1}, {0, 2}, {-2, -1}, {1, 2}},
+		},
+		OrientationTwo: {
+			OrientationRight: {{0, 0}, {0, 1}, {0, -2}, {2, 1}, {-1, -2}},
+			OrientationLeft:  {{0, 0}, {0, 2}, {0, -1}, {-1, 2}, {2, -1}},
+		},
+		OrientationLeft: {
+			OrientationTwo:   {{0, 0}, {0, -2}, {0, 1}, {1, -2}, {-2, 1}},
+			OrientationSpawn: {{0, 0}, {0, 1}, {0, -2}, {2, 1}, {-1, -2}},
+		},
+	}
+
+	// kicks180 are the wall kicks tried when a piece is rotated by 180 degrees.
+	// SRS does not define 180 degree rotations, so the kicks of the SRS+ extension, used by TETR.IO, are used.
+	kicks180 = [orientationsCount][]kick{
+		OrientationSpawn: {{0, 0}, {-1, 0}, {-1, 1}, {-1, -1}, {0, 1}, {0, -1}},
+		OrientationRight: {{0, 0}, {0, 1}, {-2, 1}, {-1, 1}, {-2, 0}, {-1, 0}},
+		OrientationTwo:   {{0, 0}, {1, 0}, {1, -1}, {1, 1}, {0, -1}, {0, 1}},
+		OrientationLeft:  {{0, 0}, {0, -1}, {-2, -1}, {-1, -1}, {-2, 0}, {-1, 0}},
+	}
+
+	kicksNone = []kick{{0, 0}}
+)
+
+// kicks returns the wall kicks to try in order when the given tetromino is turned from the given orientation.
+func kicks(tetromino Tetromino, from Orientation, turn Turn) []kick {
+	switch {
+	case tetromino == TetrominoO:
+		return kicksNone
+	case turn == Turn180:
+		return kicks180[from]
+	case tetromino == TetrominoI:
+		return kicksI[from][turn.apply(from)]
+	default:
+		return kicksJLSTZ[from][turn.apply(from)]
+	}
+}
+
+// SpawnPiece returns the given tetromino as a piece in its spawn orientation at the top of the board,
+// horizontally centered, rounding to the left.
+// Spawning a piece does not change the board, Board.Spawn should be used to also check for game over.
+// SpawnPiece panics if the tetromino is empty or not valid.
+func (b *Board) SpawnPiece(tetromino Tetromino) Piece {
+	if !tetromino.Valid() {
+		panic(fmt.Errorf("Board.SpawnPiece: invalid tetromino %d provided", tetromino))
+	}
+
+	piece := Piece{
+		Tetromino:   tetromino,
+		Orientation: OrientationSpawn,
+		Col:         (b.width - pieceBoxSizes[tetromino]) / 2,
+	}
+	if tetromino == TetrominoI {
+		// The spawn orientation of I occupies the second row of its bounding box.
+		piece.Row = -1
+	}
+
+	return piece
+}
+
+// Spawn returns the given tetromino as a piece in its spawn position.
+// If the piece does not fit there, the game is over and Spawn returns error.
+// Spawn panics if the tetromino is empty or not valid or if the board's game is already over.
+func (b *Board) Spawn(tetromino Tetromino) (Piece, error) {
+	if b.gameOver {
+		panic(fmt.Errorf("Board.Spawn: can not spawn: game is over"))
+	}
+
+	piece := b.SpawnPiece(tetromino)
+	if !b.Fits(piece) {
+		b.gameOver = true
+		return piece, fmt.Errorf("Board.Spawn: the game just ended")
+	}
+
+	return piece, nil
+}
+
+// Fits returns true if all cells of the piece are inside the board and not occupied.
+func (b *Board) Fits(piece Piece) bool {
+	for i, mask := range pieceMasks[piece.Tetromino][piece.Orientation] {
+		if mask == 0 {
+			continue
+		}
+
+		row := piece.Row + i
+		if row < 0 || row >= b.height {
+			return false
+		}
+
+		var shifted uint32
+		if piece.Col >= 0 {
+			wide := uint64(mask) << uint(piece.Col)
+			if wide > uint64(b.fullRow) {
+				return false // The piece sticks out of the right side of the board.
+			}
+			shifted = uint32(wide)
+		} else {
+			if mask&(1<<uint(-piece.Col)-1) != 0 {
+				return false // The piece sticks out of the left side of the board.
+			}
+			shifted = mask >> uint(-piece.Col)
+		}
+
+		if b.rows[row]&shifted != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MoveLeft returns the piece shifted one column to the left.
+// If the piece can not be moved, it is returned unchanged with false.
+func (b *Board) MoveLeft(piece Piece) (Piece, bool) {
+	return b.move(piece, 0, -1)
+}
+
+// MoveRight returns the piece shifted one column to the right.
+// If the piece can not be moved, it is returned unchanged with false.
+func (b *Board) MoveRight(piece Piece) (Piece, bool) {
+	return b.move(piece, 0, 1)
+}
+
+// MoveDown returns the piece moved one row down (soft dropped).
+// If the piece can not be moved, it is returned unchanged with false.
+func (b *Board) MoveDown(piece Piece) (Piece, bool) {
+	return b.move(piece, 1, 0)
+}
+
+// move returns the piece moved by the given number of rows and columns, if it fits there.
+func (b *Board) move(piece Piece, rows, cols int) (Piece, bool) {
+	moved := piece.moved(rows, cols)
+	if !b.Fits(moved) {
+		return piece, false
+	}
+	return moved, true
+}
+
+// Rotate returns the piece rotated with the given turn.
+// If the rotated piece does not fit, the wall kicks of the Super Rotation System are tried in order.
+// If none of them fit, the piece is returned unchanged with false.
+// A rotated T piece records the T-spin it would be locked with, see Piece.TSpin.
+func (b *Board) Rotate(piece Piece, turn Turn) (Piece, bool) {
+	rotated := piece
+	rotated.Orientation = turn.apply(piece.Orientation)
+
+	for i, kick := range kicks(piece.Tetromino, piece.Orientation, turn) {
+		kicked := rotated.moved(kick.rows, kick.cols)
+		if b.Fits(kicked) {
+			kicked.spin = b.tSpin(kicked, turn, i)
+			return kicked, true
+		}
+	}
+
+	return piece, false
+}
+
+// Ghost returns the piece moved down as far as it goes - where it would be locked if it was hard dropped.
+func (b *Board) Ghost(piece Piece) Piece {
+	for {
+		moved, ok := b.MoveDown(piece)
+		if !ok {
+			return piece
+		}
+		piece = moved
+	}
+}
+
+// HardDrop moves the piece down as far as it goes and locks it there, returning the resulting line clear.
+// The points for hard dropping the piece are awarded.
+// HardDrop panics if the piece does not fit on the board or if the board's game is already over.
+func (b *Board) HardDrop(piece Piece) LineClear {
+	ghost := b.Ghost(piece)
+	clear := b.Lock(ghost)
+	b.score += hardDropPoints * (ghost.Row - piece.Row)
+	return clear
+}
+
+// Place locks the resting piece of a placement, see Placements, and awards the points for hard dropping it
+// from the row it spawns in, like HardDrop does for a piece that is moved into place at the top of the board.
+// Players that choose placements instead of moving pieces use it, so that their scores match human players'.
+// Place panics if the piece does not fit on the board or if the board's game is already over.
+func (b *Board) Place(piece Piece) LineClear {
+	clear := b.Lock(piece)
+	if rows := piece.Row - b.SpawnPiece(piece.Tetromino).Row; rows > 0 {
+		b.score += hardDropPoints * rows
+	}
+	return clear
+}
+
+// Lock locks the piece in its current position, clears any full rows and updates the board's statistics.
+// It returns the resulting line clear, which includes the piece's T-spin.
+// The piece does not need to be resting on the stack.
+// Lock panics if the piece does not fit on the board or if the board's game is already over.
+func (b *Board) Lock(piece Piece) LineClear {
+	if b.gameOver {
+		panic(fmt.Errorf("Board.Lock: can not lock: game is over"))
+	}
+
+	if !piece.Tetromino.Valid() || !b.Fits(piece) {
+		panic(fmt.Errorf("Board.Lock: can not lock piece %v: it does not fit on the board", piece))
+	}
+
+	var (
+		cells     = piece.Cells()
+		top       = cells[0].Row
+		pieceRows [4]uint32
+	)
+	for _, cell := range cells {
+		b.rows[cell.Row] |= 1 << uint(cell.Col)
+		pieceRows[cell.Row-top] |= 1 << uint(cell.Col)
+		if b.colors != nil {
+			b.colors[cell.Row*b.width+cell.Col] = piece.Tetromino
+		}
+	}
+
+	b.locked(piece.spin, top, pieceRows[:cells[len(cells)-1].Row-top+1])
+	return b.lastClear
+}
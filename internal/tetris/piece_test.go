@@ -0,0 +1,176 @@
+package tetris_test
+
+import (
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+func cells(rowCols ...int) [4]tetris.Cell {
+	var cells [4]tetris.Cell
+	for i := range cells {
+		cells[i] = tetris.Cell{Row: rowCols[2*i], Col: rowCols[2*i+1]}
+	}
+	return cells
+}
+
+func TestBoardSpawnPiece(t *testing.T) {
+	board := tetris.NewBoard()
+
+	tests := []struct {
+		tetromino tetris.Tetromino
+		cells     [4]tetris.Cell
+	}{
+		{tetromino: I, cells: cells(0, 3, 0, 4, 0, 5, 0, 6)},
+		{tetromino: J, cells: cells(0, 3, 1, 3, 1, 4, 1, 5)},
+		{tetromino: L, cells: cells(0, 5, 1, 3, 1, 4, 1, 5)},
+		{tetromino: O, cells: cells(0, 4, 0, 5, 1, 4, 1, 5)},
+		{tetromino: S, cells: cells(0, 4, 0, 5, 1, 3, 1, 4)},
+		{tetromino: T, cells: cells(0, 4, 1, 3, 1, 4, 1, 5)},
+		{tetromino: Z, cells: cells(0, 3, 0, 4, 1, 4, 1, 5)},
+	}
+
+	for _, test := range tests {
+		piece := board.SpawnPiece(test.tetromino)
+		assert.Equal(t, tetris.OrientationSpawn, piece.Orientation)
+		assert.Equal(t, test.cells, piece.Cells(), "tetromino %s", test.tetromino)
+	}
+
+	assert.Panics(t, func() { board.SpawnPiece(Empty) })
+}
+
+func TestBoardRotate(t *testing.T) {
+	board := tetris.NewBoard()
+	piece := board.SpawnPiece(T)
+
+	right, ok := board.Rotate(piece, tetris.TurnClockwise)
+	assert.True(t, ok)
+	assert.Equal(t, tetris.OrientationRight, right.Orientation)
+	assert.Equal(t, cells(0, 4, 1, 4, 1, 5, 2, 4), right.Cells())
+
+	two, ok := board.Rotate(right, tetris.TurnClockwise)
+	assert.True(t, ok)
+	assert.Equal(t, cells(1, 3, 1, 4, 1, 5, 2, 4), two.Cells())
+
+	left, ok := board.Rotate(piece, tetris.TurnCounterClockwise)
+	assert.True(t, ok)
+	assert.Equal(t, tetris.OrientationLeft, left.Orientation)
+	assert.Equal(t, cells(0, 4, 1, 3, 1, 4, 2, 4), left.Cells())
+
+	flipped, ok := board.Rotate(right, tetris.Turn180)
+	assert.True(t, ok)
+	assert.Equal(t, left.Cells(), flipped.Cells())
+}
+
+func TestBoardRotateOIsNeverMoved(t *testing.T) {
+	board := tetris.NewBoard()
+	piece := board.SpawnPiece(O)
+
+	for _, turn := range []tetris.Turn{tetris.TurnClockwise, tetris.TurnCounterClockwise, tetris.Turn180} {
+		rotated, ok := board.Rotate(piece, turn)
+		assert.True(t, ok)
+		assert.Equal(t, piece.Cells(), rotated.Cells())
+	}
+}
+
+func TestBoardRotateKicksOffWall(t *testing.T) {
+	board := tetris.NewBoard()
+	piece := tetris.Piece{Tetromino: T, Orientation: tetris.OrientationRight, Row: 5, Col: -1}
+	assert.True(t, board.Fits(piece))
+
+	rotated, ok := board.Rotate(piece, tetris.TurnCounterClockwise)
+	assert.True(t, ok)
+	assert.Equal(t, tetris.OrientationSpawn, rotated.Orientation)
+	assert.Equal(t, 0, rotated.Col)
+	assert.Equal(t, 5, rotated.Row)
+}
+
+func TestBoardRotateFailsWhenNoKickFits(t *testing.T) {
+	// Only the leftmost column of the board is empty.
+	board := tetris.NewBoardWithSize(4, 20)
+	for col := 1; col < 4; col++ {
+		board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 12, Col: col - 2})
+		board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 16, Col: col - 2})
+	}
+
+	piece := board.Ghost(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 0, Col: -2})
+	assert.Equal(t, 16, piece.Row)
+
+	rotated, ok := board.Rotate(piece, tetris.TurnClockwise)
+	assert.False(t, ok)
+	assert.Equal(t, piece, rotated)
+}
+
+func TestBoardMove(t *testing.T) {
+	board := tetris.NewBoard()
+	piece := board.SpawnPiece(I)
+
+	for i := 0; i < 3; i++ {
+		var ok bool
+		piece, ok = board.MoveLeft(piece)
+		assert.True(t, ok)
+	}
+
+	_, ok := board.MoveLeft(piece)
+	assert.False(t, ok)
+
+	piece, ok = board.MoveRight(piece)
+	assert.True(t, ok)
+	assert.Equal(t, cells(0, 1, 0, 2, 0, 3, 0, 4), piece.Cells())
+
+	piece, ok = board.MoveDown(piece)
+	assert.True(t, ok)
+	assert.Equal(t, cells(1, 1, 1, 2, 1, 3, 1, 4), piece.Cells())
+
+	ghost := board.Ghost(piece)
+	assert.Equal(t, cells(19, 1, 19, 2, 19, 3, 19, 4), ghost.Cells())
+	_, ok = board.MoveDown(ghost)
+	assert.False(t, ok)
+}
+
+func TestBoardLockTucksUnderOverhang(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Lock(tetris.Piece{Tetromino: O, Row: 16, Col: 0})
+
+	piece := board.Ghost(board.SpawnPiece(O))
+	for i := 0; i < 4; i++ {
+		var ok bool
+		piece, ok = board.MoveLeft(piece)
+		assert.True(t, ok)
+	}
+	board.Lock(piece)
+
+	assert.Equal(t, O, board.At(16, 0))
+	assert.Equal(t, O, board.At(19, 0))
+	assert.Equal(t, 2, board.DroppedTetrominoes())
+	assert.Equal(t, []int{4, 4, 0, 0, 0, 0, 0, 0, 0, 0}, board.HeightsByColumn())
+	assert.Equal(t, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, board.HolesByColumn())
+}
+
+func TestBoardHardDropClearsLines(t *testing.T) {
+	board := tetris.NewBoardWithSize(4, 20)
+	board.HardDrop(board.SpawnPiece(I))
+
+	assert.Equal(t, 1, board.ClearedLines())
+	assert.Equal(t, []int{0, 0, 0, 0}, board.HeightsByColumn())
+}
+
+func TestBoardLockPanics(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Lock(tetris.Piece{Tetromino: O, Row: 18, Col: 0})
+
+	assert.Panics(t, func() { board.Lock(tetris.Piece{Tetromino: O, Row: 18, Col: 1}) })
+	assert.Panics(t, func() { board.Lock(tetris.Piece{Tetromino: O, Row: 19, Col: 4}) })
+	assert.Panics(t, func() { board.Lock(tetris.Piece{Tetromino: Empty, Row: 10, Col: 4}) })
+}
+
+func TestBoardSpawnReturnsErrorOnGameOver(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Lock(tetris.Piece{Tetromino: O, Row: 0, Col: 4})
+
+	_, err := board.Spawn(T)
+	assert.NotNil(t, err)
+	assert.True(t, board.GameOver())
+	assert.Panics(t, func() { board.Spawn(T) })
+}
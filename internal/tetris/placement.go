@@ -0,0 +1,359 @@
+package tetris
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Move is an input that moves a piece on the board.
+type Move int
+
+// The possible moves of a piece.
+// MoveDown moves the piece one row down, while MoveSoftDrop moves it down as far as it goes without locking it.
+const (
+	MoveLeft Move = iota
+	MoveRight
+	MoveDown
+	MoveSoftDrop
+	MoveRotateClockwise
+	MoveRotateCounterClockwise
+	MoveRotate180
+)
+
+// searchMoves contains all possible moves, in the order in which they are tried when searching for placements.
+var searchMoves = []Move{
+	MoveSoftDrop,
+	MoveLeft,
+	MoveRight,
+	MoveRotateClockwise,
+	MoveRotateCounterClockwise,
+	MoveRotate180,
+	MoveDown,
+}
+
+// Move implements Stringer.
+func (m Move) String() string {
+	switch m {
+	case MoveLeft:
+		return "left"
+	case MoveRight:
+		return "right"
+	case MoveDown:
+		return "down"
+	case MoveSoftDrop:
+		return "soft drop"
+	case MoveRotateClockwise:
+		return "rotate CW"
+	case MoveRotateCounterClockwise:
+		return "rotate CCW"
+	case MoveRotate180:
+		return "rotate 180"
+	default:
+		panic(fmt.Errorf("Move.String: invalid move %d provided", m))
+	}
+}
+
+// rotates returns true if the move rotates the piece.
+func (m Move) rotates() bool {
+	return m == MoveRotateClockwise || m == MoveRotateCounterClockwise || m == MoveRotate180
+}
+
+// Apply returns the piece moved with the given move.
+// If the piece can not be moved, it is returned unchanged with false.
+// Apply panics if the move is invalid.
+func (b *Board) Apply(piece Piece, move Move) (Piece, bool) {
+	switch move {
+	case MoveLeft:
+		return b.MoveLeft(piece)
+	case MoveRight:
+		return b.MoveRight(piece)
+	case MoveDown:
+		return b.MoveDown(piece)
+	case MoveSoftDrop:
+		ghost := b.Ghost(piece)
+		return ghost, ghost != piece
+	case MoveRotateClockwise:
+		return b.Rotate(piece, TurnClockwise)
+	case MoveRotateCounterClockwise:
+		return b.Rotate(piece, TurnCounterClockwise)
+	case MoveRotate180:
+		return b.Rotate(piece, Turn180)
+	default:
+		panic(fmt.Errorf("Board.Apply: invalid move %d provided", move))
+	}
+}
+
+// Placement is a resting position of a tetromino on a board
+// together with the moves that take it there from its spawn position.
+// The piece is expected to be hard dropped (or locked) after the moves.
+type Placement struct {
+	Piece Piece
+	Moves []Move
+}
+
+// Placements returns every distinct resting placement of the given tetromino on the board
+// that can be reached from its spawn position by shifting, rotating (with wall kicks) and soft dropping it.
+// Placements that occupy the same cells are only returned once, with the most valuable T-spin they can be reached with
+// and the shortest sequence of moves that reaches them with it.
+// If the tetromino can not be spawned, no placements are returned.
+// Placements panics if the tetromino is empty or not valid.
+func (b *Board) Placements(tetromino Tetromino) []Placement {
+	search := placementSearches.Get().(*placementSearch)
+	defer placementSearches.Put(search)
+
+	return search.run(b, tetromino)
+}
+
+// placementSearches is a pool of placementSearch, so that their memory is reused between searches.
+var placementSearches = sync.Pool{
+	New: func() interface{} {
+		return &placementSearch{}
+	},
+}
+
+// placementSearch is a breadth-first search of the positions of a piece reachable from its spawn position.
+// A position is a piece's T-spin, orientation, row and column and is indexed by placementSearch.index.
+type placementSearch struct {
+	// rows and cols are the number of possible rows and columns of a piece's bounding box.
+	// The bounding box may stick out of the board by up to boxOffset cells to the top and left.
+	rows int
+	cols int
+
+	visited []bool
+	parent  []int32
+	move    []Move
+	queue   []int32
+
+	// rested contains for the cells, indexed like positions without a T-spin, on which some piece has been found
+	// to rest, the index of the piece in the resting positions, offset by one so that zero means none.
+	// Resting pieces are identified by their shape and their first cell, see shapeOrientations.
+	rested []int32
+
+	// landings caches the row in which pieces above the surface land, by orientation and column.
+	// The row is offset by boxOffset+1, so that zero means unknown.
+	landings []int
+}
+
+// boxOffset is how many cells the bounding box of a piece can stick out of the board to the top or left.
+const boxOffset = 3
+
+// shapeOrientations contains for each tetromino and orientation the first orientation of the tetromino
+// in which its cells have the same shape. For example, the I tetromino looks the same in its spawn orientation
+// and in orientation two, only moved by a row.
+var shapeOrientations [][orientationsCount]Orientation
+
+// pieceBottoms contains for each tetromino and orientation the lowest row of the bounding box
+// which contains a cell of the piece.
+var pieceBottoms [][orientationsCount]int
+
+func init() {
+	shapeOrientations = make([][orientationsCount]Orientation, len(pieceCells))
+	pieceBottoms = make([][orientationsCount]int, len(pieceCells))
+
+	for tetromino := range pieceCells {
+		for orientation := range pieceCells[tetromino] {
+			cells := pieceCells[tetromino][orientation]
+			pieceBottoms[tetromino][orientation] = cells[len(cells)-1].Row
+
+			shapeOrientations[tetromino][orientation] = Orientation(orientation)
+			for other := 0; other < orientation; other++ {
+				if sameShape(cells, pieceCells[tetromino][other]) {
+					shapeOrientations[tetromino][orientation] = Orientation(other)
+					break
+				}
+			}
+		}
+	}
+}
+
+// sameShape returns true if the given sorted cells are the same, only moved by some rows and columns.
+func sameShape(a, b [4]Cell) bool {
+	for i := range a {
+		if a[i].Row-a[0].Row != b[i].Row-b[0].Row || a[i].Col-a[0].Col != b[i].Col-b[0].Col {
+			return false
+		}
+	}
+	return true
+}
+
+// index returns the index of the position of the given piece.
+func (s *placementSearch) index(piece Piece) int32 {
+	orientation := int(piece.spin)*orientationsCount + int(piece.Orientation)
+	return int32((orientation*s.rows+piece.Row+boxOffset)*s.cols + piece.Col + boxOffset)
+}
+
+// piece returns the piece of the given tetromino at the position with the given index.
+func (s *placementSearch) piece(tetromino Tetromino, index int32) Piece {
+	i := int(index)
+	orientation := i / (s.rows * s.cols)
+	return Piece{
+		Tetromino:   tetromino,
+		Orientation: Orientation(orientation % orientationsCount),
+		Row:         i/s.cols%s.rows - boxOffset,
+		Col:         i%s.cols - boxOffset,
+		spin:        TSpin(orientation / orientationsCount),
+	}
+}
+
+// reset prepares the search of the given tetromino's positions on the given board.
+func (s *placementSearch) reset(board *Board, tetromino Tetromino) {
+	s.rows = board.height + boxOffset
+	s.cols = board.width + boxOffset
+
+	// Only T pieces can be spun, so only they have positions with T-spins.
+	spins := 1
+	if tetromino == TetrominoT {
+		spins = tSpinsCount
+	}
+
+	size := spins * orientationsCount * s.rows * s.cols
+	if cap(s.visited) < size {
+		s.visited = make([]bool, size)
+		s.parent = make([]int32, size)
+		s.move = make([]Move, size)
+		s.rested = make([]int32, size)
+	}
+
+	s.visited = s.visited[:size]
+	s.parent = s.parent[:size]
+	s.move = s.move[:size]
+	s.rested = s.rested[:size]
+	for i := range s.visited {
+		s.visited[i] = false
+		s.rested[i] = 0
+	}
+	s.queue = s.queue[:0]
+
+	if cap(s.landings) < orientationsCount*s.cols {
+		s.landings = make([]int, orientationsCount*s.cols)
+	}
+	s.landings = s.landings[:orientationsCount*s.cols]
+	for i := range s.landings {
+		s.landings[i] = 0
+	}
+}
+
+// run returns the placements of the given tetromino on the given board.
+func (s *placementSearch) run(board *Board, tetromino Tetromino) []Placement {
+	s.reset(board, tetromino)
+
+	spawn := board.SpawnPiece(tetromino)
+	if !board.Fits(spawn) {
+		return nil
+	}
+
+	// surface is the highest row which contains an occupied cell.
+	// Pieces which are entirely above it can move freely, so moving them down row by row is not needed
+	// and they are moved straight down to the surface instead.
+	surface := board.height
+	for row, mask := range board.rows {
+		if mask != 0 {
+			surface = row
+			break
+		}
+	}
+
+	var resting []int32
+
+	start := s.index(spawn)
+	s.visited[start] = true
+	s.parent[start] = -1
+	s.queue = append(s.queue, start)
+
+	for head := 0; head < len(s.queue); head++ {
+		current := s.queue[head]
+		piece := s.piece(tetromino, current)
+
+		if _, ok := board.MoveDown(piece); !ok {
+			first := piece.Cells()[0]
+			key := s.index(Piece{Orientation: shapeOrientations[tetromino][piece.Orientation], Row: first.Row, Col: first.Col})
+			switch rested := s.rested[key]; {
+			case rested == 0:
+				resting = append(resting, current)
+				s.rested[key] = int32(len(resting))
+			case piece.spin > s.piece(tetromino, resting[rested-1]).spin:
+				resting[rested-1] = current
+			}
+		}
+
+		bottom := piece.Row + pieceBottoms[tetromino][piece.Orientation]
+		for _, move := range searchMoves {
+			var (
+				next Piece
+				ok   bool
+			)
+
+			switch {
+			case tetromino == TetrominoO && move.rotates():
+				// Rotating O never changes the cells it occupies.
+				continue
+
+			case move == MoveDown && bottom+1 < surface:
+				next, ok = board.move(piece, surface-1-bottom, 0)
+
+			case move == MoveSoftDrop && bottom < surface:
+				// Pieces above the surface land in the same row regardless of the row they are dropped from.
+				landing := &s.landings[int(piece.Orientation)*s.cols+piece.Col+boxOffset]
+				if *landing == 0 {
+					*landing = board.Ghost(piece).Row + boxOffset + 1
+				}
+				next = piece.moved(*landing-boxOffset-1-piece.Row, 0)
+				ok = next.Row != piece.Row
+
+			default:
+				next, ok = board.Apply(piece, move)
+			}
+
+			if !ok {
+				continue
+			}
+
+			index := s.index(next)
+			if s.visited[index] {
+				continue
+			}
+
+			s.visited[index] = true
+			s.parent[index] = current
+			s.move[index] = move
+			s.queue = append(s.queue, index)
+		}
+	}
+
+	return s.placements(tetromino, resting)
+}
+
+// placements returns the placements of the given tetromino at the given resting positions,
+// with the moves that reach them.
+func (s *placementSearch) placements(tetromino Tetromino, resting []int32) []Placement {
+	placements := make([]Placement, len(resting))
+
+	var moves []Move
+	for i, index := range resting {
+		from := len(moves)
+
+		for current := index; s.parent[current] != -1; current = s.parent[current] {
+			move := s.move[current]
+			moves = append(moves, move)
+
+			// Pieces moved straight down to the surface are moved by more than one row.
+			if move == MoveDown {
+				rows := s.piece(tetromino, current).Row - s.piece(tetromino, s.parent[current]).Row
+				for row := 1; row < rows; row++ {
+					moves = append(moves, MoveDown)
+				}
+			}
+		}
+
+		for l, r := from, len(moves)-1; l < r; l, r = l+1, r-1 {
+			moves[l], moves[r] = moves[r], moves[l]
+		}
+
+		placements[i] = Placement{
+			Piece: s.piece(tetromino, index),
+			Moves: moves[from:len(moves):len(moves)],
+		}
+	}
+
+	return placements
+}
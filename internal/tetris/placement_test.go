@@ -0,0 +1,95 @@
+package tetris_test
+
+import (
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardPlacementsOnEmptyBoard(t *testing.T) {
+	board := tetris.NewBoard()
+
+	expected := map[tetris.Tetromino]int{
+		I: 17,
+		J: 34,
+		L: 34,
+		O: 9,
+		S: 17,
+		T: 34,
+		Z: 17,
+	}
+
+	for tetromino, count := range expected {
+		assert.Len(t, board.Placements(tetromino), count, "tetromino %s", tetromino)
+	}
+}
+
+func TestBoardPlacementsMovesReachPlacement(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Lock(tetris.Piece{Tetromino: O, Row: 16, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 14, Col: 5})
+
+	for _, tetromino := range tetris.Tetrominoes() {
+		for _, placement := range board.Placements(tetromino) {
+			piece := board.SpawnPiece(tetromino)
+			for _, move := range placement.Moves {
+				var ok bool
+				piece, ok = board.Apply(piece, move)
+				assert.True(t, ok, "tetromino %s, moves %v", tetromino, placement.Moves)
+			}
+
+			assert.Equal(t, placement.Piece, piece)
+			assert.Equal(t, piece, board.Ghost(piece), "placement %v is not resting", placement)
+		}
+	}
+}
+
+func TestBoardPlacementsAreDistinct(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Drop(T, 2, 0)
+
+	for _, tetromino := range tetris.Tetrominoes() {
+		seen := make(map[[4]tetris.Cell]bool)
+		for _, placement := range board.Placements(tetromino) {
+			cells := placement.Piece.Cells()
+			assert.False(t, seen[cells], "tetromino %s, cells %v", tetromino, cells)
+			seen[cells] = true
+		}
+	}
+}
+
+func TestBoardPlacementsReachUnderOverhang(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Lock(tetris.Piece{Tetromino: O, Row: 16, Col: 0})
+
+	var tucked *tetris.Placement
+	for _, placement := range board.Placements(O) {
+		if placement.Piece.Row == 18 && placement.Piece.Col == 0 {
+			tucked = &placement
+			break
+		}
+	}
+
+	if assert.NotNil(t, tucked) {
+		assert.Equal(t, tetris.MoveLeft, tucked.Moves[len(tucked.Moves)-1])
+	}
+}
+
+func TestBoardPlacementsWhenSpawnIsBlocked(t *testing.T) {
+	board := tetris.NewBoard()
+	board.Lock(tetris.Piece{Tetromino: O, Row: 0, Col: 4})
+
+	assert.Empty(t, board.Placements(T))
+}
+
+func BenchmarkBoardPlacements(b *testing.B) {
+	board := tetris.NewBoard()
+	board.Drop(T, 0, 0)
+	board.Drop(L, 1, 4)
+	board.Drop(I, 0, 9)
+
+	for i := 0; i < b.N; i++ {
+		board.Placements(tetris.Tetrominoes()[i%tetris.TetrominoesCount])
+	}
+}
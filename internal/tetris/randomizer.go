@@ -0,0 +1,316 @@
+package tetris
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Randomizer generates the sequence of tetrominoes that are dealt in a game.
+// See https://tetris.wiki/Random_Generator and https://tetris.wiki/TGM_randomizer for the algorithms.
+type Randomizer interface {
+	// Next returns the next tetromino of the sequence.
+	Next() Tetromino
+}
+
+// Distribution is implemented by randomizers that know how likely each tetromino is to be dealt next.
+// All randomizers created with NewRandomizer implement it.
+type Distribution interface {
+	// Probabilities returns the probability of each tetromino to be the next one returned by Next,
+	// indexed by tetromino. The probability of TetrominoEmpty is 0.
+	Probabilities() [TetrominoesCount + 1]float64
+}
+
+// BoardObserver is implemented by randomizers that deal tetrominoes depending on the board they are dealt on,
+// e.g. the tetromino that is the worst for it. A game sets its board to its randomizer before dealing any tetrominoes.
+type BoardObserver interface {
+	SetBoard(board *Board)
+}
+
+// NextProbabilities returns the probability of each tetromino to be dealt next by the given randomizer,
+// indexed by tetromino. If the randomizer does not implement Distribution, each tetromino is equally likely.
+func NextProbabilities(randomizer Randomizer) [TetrominoesCount + 1]float64 {
+	if distribution, ok := randomizer.(Distribution); ok {
+		return distribution.Probabilities()
+	}
+	return uniformProbabilities()
+}
+
+// uniformProbabilities returns the same probability for each tetromino, indexed by tetromino.
+func uniformProbabilities() [TetrominoesCount + 1]float64 {
+	var probabilities [TetrominoesCount + 1]float64
+	for _, tetromino := range Tetrominoes() {
+		probabilities[tetromino] = 1.0 / TetrominoesCount
+	}
+	return probabilities
+}
+
+// The names of the randomizers that can be created with NewRandomizer.
+const (
+	RandomizerUniform = "uniform"
+	Randomizer7Bag    = "7-bag"
+	Randomizer14Bag   = "14-bag"
+	RandomizerTGM     = "tgm"
+	RandomizerNES     = "nes"
+)
+
+// RandomizerFactory creates a randomizer that draws random numbers from rng.
+type RandomizerFactory func(rng *rand.Rand) Randomizer
+
+// randomizerFactories contains the registered factories of randomizers by name.
+var randomizerFactories = make(map[string]RandomizerFactory)
+
+// RegisterRandomizer makes a randomizer implemented outside of this package available by the given name
+// in NewRandomizer. RegisterRandomizer is meant to be called from the init function of the package that implements it.
+// RegisterRandomizer panics if a randomizer with the same name already exists.
+func RegisterRandomizer(name string, factory RandomizerFactory) {
+	for _, existing := range RandomizerNames() {
+		if existing == name {
+			panic(fmt.Errorf("RegisterRandomizer: randomizer %q already exists", name))
+		}
+	}
+	randomizerFactories[name] = factory
+}
+
+// RandomizerNames returns the names of all randomizers that can be created with NewRandomizer:
+// the ones of this package, followed by the sorted names of the registered ones.
+func RandomizerNames() []string {
+	names := []string{
+		RandomizerUniform,
+		Randomizer7Bag,
+		Randomizer14Bag,
+		RandomizerTGM,
+		RandomizerNES,
+	}
+
+	registered := make([]string, 0, len(randomizerFactories))
+	for name := range randomizerFactories {
+		registered = append(registered, name)
+	}
+	sort.Strings(registered)
+
+	return append(names, registered...)
+}
+
+// NewRandomizer creates the randomizer with the given name, drawing random numbers from rng.
+// NewRandomizer returns error if there is no randomizer with that name, see RandomizerNames.
+func NewRandomizer(name string, rng *rand.Rand) (Randomizer, error) {
+	switch name {
+	case RandomizerUniform:
+		return NewUniformRandomizer(rng), nil
+	case Randomizer7Bag:
+		return NewBagRandomizer(rng, 1), nil
+	case Randomizer14Bag:
+		return NewBagRandomizer(rng, 2), nil
+	case RandomizerTGM:
+		return NewHistoryRandomizer(rng), nil
+	case RandomizerNES:
+		return NewNESRandomizer(rng), nil
+	}
+
+	factory, ok := randomizerFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("NewRandomizer: unknown randomizer %q", name)
+	}
+	return factory(rng), nil
+}
+
+// uniformRandomizer deals each tetromino with the same probability, independently of the previous ones.
+type uniformRandomizer struct {
+	rng *rand.Rand
+}
+
+// NewUniformRandomizer creates a randomizer that deals each tetromino with the same probability,
+// independently of the previous ones.
+func NewUniformRandomizer(rng *rand.Rand) Randomizer {
+	return &uniformRandomizer{rng: rng}
+}
+
+// Next implements Randomizer.
+func (r *uniformRandomizer) Next() Tetromino {
+	return Tetromino(1 + r.rng.Intn(TetrominoesCount))
+}
+
+// Probabilities implements Distribution.
+func (r *uniformRandomizer) Probabilities() [TetrominoesCount + 1]float64 {
+	return uniformProbabilities()
+}
+
+// bagRandomizer deals the tetrominoes of a shuffled bag before refilling it.
+type bagRandomizer struct {
+	rng    *rand.Rand
+	copies int
+	bag    []Tetromino
+}
+
+// NewBagRandomizer creates a randomizer that puts the given number of copies of each tetromino in a bag,
+// and deals them in a random order before refilling the bag.
+// With one copy, this is the 7-bag Random Generator of the Tetris guideline.
+// NewBagRandomizer panics if the number of copies is not positive.
+func NewBagRandomizer(rng *rand.Rand, copies int) Randomizer {
+	if copies < 1 {
+		panic(fmt.Errorf("NewBagRandomizer: invalid number of copies %d provided", copies))
+	}
+
+	return &bagRandomizer{
+		rng:    rng,
+		copies: copies,
+		bag:    make([]Tetromino, 0, copies*TetrominoesCount),
+	}
+}
+
+// Next implements Randomizer.
+func (r *bagRandomizer) Next() Tetromino {
+	if len(r.bag) == 0 {
+		for i := 0; i < r.copies; i++ {
+			r.bag = append(r.bag, Tetrominoes()...)
+		}
+		r.rng.Shuffle(len(r.bag), func(i, j int) {
+			r.bag[i], r.bag[j] = r.bag[j], r.bag[i]
+		})
+	}
+
+	tetromino := r.bag[len(r.bag)-1]
+	r.bag = r.bag[:len(r.bag)-1]
+	return tetromino
+}
+
+// Probabilities implements Distribution. The next tetromino is one of those left in the bag.
+func (r *bagRandomizer) Probabilities() [TetrominoesCount + 1]float64 {
+	if len(r.bag) == 0 {
+		// The bag is refilled with the same number of copies of each tetromino.
+		return uniformProbabilities()
+	}
+
+	var probabilities [TetrominoesCount + 1]float64
+	for _, tetromino := range r.bag {
+		probabilities[tetromino] += 1 / float64(len(r.bag))
+	}
+	return probabilities
+}
+
+// historyRandomizer is the randomizer of Tetris The Grand Master. It remembers the last four tetrominoes
+// and rerolls a tetromino that is among them up to historyRolls times.
+type historyRandomizer struct {
+	rng     *rand.Rand
+	history [4]Tetromino
+	first   bool
+}
+
+// historyRolls is the number of times historyRandomizer draws a tetromino before accepting one that is in its history.
+const historyRolls = 4
+
+// NewHistoryRandomizer creates the randomizer of Tetris The Grand Master: a tetromino that is one of the last four
+// dealt tetrominoes is rerolled up to three times, which makes repeats rare.
+// The history starts filled with Z and the first tetromino is never S, Z or O.
+func NewHistoryRandomizer(rng *rand.Rand) Randomizer {
+	return &historyRandomizer{
+		rng:     rng,
+		history: [4]Tetromino{TetrominoZ, TetrominoZ, TetrominoZ, TetrominoZ},
+		first:   true,
+	}
+}
+
+// Next implements Randomizer.
+func (r *historyRandomizer) Next() Tetromino {
+	var tetromino Tetromino
+	if r.first {
+		r.first = false
+		first := []Tetromino{TetrominoI, TetrominoJ, TetrominoL, TetrominoT}
+		tetromino = first[r.rng.Intn(len(first))]
+	} else {
+		for roll := 0; roll < historyRolls; roll++ {
+			tetromino = Tetromino(1 + r.rng.Intn(TetrominoesCount))
+			if !r.inHistory(tetromino) {
+				break
+			}
+		}
+	}
+
+	copy(r.history[1:], r.history[:len(r.history)-1])
+	r.history[0] = tetromino
+	return tetromino
+}
+
+// Probabilities implements Distribution.
+func (r *historyRandomizer) Probabilities() [TetrominoesCount + 1]float64 {
+	var probabilities [TetrominoesCount + 1]float64
+	if r.first {
+		for _, tetromino := range []Tetromino{TetrominoI, TetrominoJ, TetrominoL, TetrominoT} {
+			probabilities[tetromino] = 1.0 / 4
+		}
+		return probabilities
+	}
+
+	// Each roll but the last is accepted if it is not in the history, the last one is always accepted.
+	var inHistory float64
+	for _, tetromino := range Tetrominoes() {
+		if r.inHistory(tetromino) {
+			inHistory += 1.0 / TetrominoesCount
+		}
+	}
+	lastRoll := math.Pow(inHistory, historyRolls-1)
+
+	for _, tetromino := range Tetrominoes() {
+		probabilities[tetromino] = lastRoll / TetrominoesCount
+		if !r.inHistory(tetromino) {
+			// The tetromino is accepted by one of the rolls before the last one.
+			probabilities[tetromino] += (1 - lastRoll) / (1 - inHistory) / TetrominoesCount
+		}
+	}
+	return probabilities
+}
+
+// inHistory returns true if the tetromino is one of the last dealt ones.
+func (r *historyRandomizer) inHistory(tetromino Tetromino) bool {
+	for _, t := range r.history {
+		if t == tetromino {
+			return true
+		}
+	}
+	return false
+}
+
+// nesRandomizer is the randomizer of the NES version of Tetris.
+// It draws one of eight options, one of which is invalid, and rerolls once
+// if it draws the invalid option or the previous tetromino.
+type nesRandomizer struct {
+	rng      *rand.Rand
+	previous Tetromino
+}
+
+// NewNESRandomizer creates the randomizer of the NES version of Tetris, which makes repeats less likely.
+func NewNESRandomizer(rng *rand.Rand) Randomizer {
+	return &nesRandomizer{rng: rng}
+}
+
+// Next implements Randomizer.
+func (r *nesRandomizer) Next() Tetromino {
+	// Zero stands for the invalid option.
+	tetromino := Tetromino(r.rng.Intn(TetrominoesCount + 1))
+	if tetromino == TetrominoEmpty || tetromino == r.previous {
+		tetromino = Tetromino(1 + r.rng.Intn(TetrominoesCount))
+	}
+
+	r.previous = tetromino
+	return tetromino
+}
+
+// Probabilities implements Distribution.
+func (r *nesRandomizer) Probabilities() [TetrominoesCount + 1]float64 {
+	// The invalid option and the previous tetromino, if any, are rerolled.
+	rerolled := 1.0 / (TetrominoesCount + 1)
+	if r.previous != TetrominoEmpty {
+		rerolled += 1.0 / (TetrominoesCount + 1)
+	}
+
+	var probabilities [TetrominoesCount + 1]float64
+	for _, tetromino := range Tetrominoes() {
+		probabilities[tetromino] = rerolled / TetrominoesCount
+		if tetromino != r.previous {
+			probabilities[tetromino] += 1.0 / (TetrominoesCount + 1)
+		}
+	}
+	return probabilities
+}
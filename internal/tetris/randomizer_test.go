@@ -0,0 +1,141 @@
+package tetris_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRandomizer(t *testing.T) {
+	for _, name := range tetris.RandomizerNames() {
+		randomizer, err := tetris.NewRandomizer(name, rand.New(rand.NewSource(1)))
+		if !assert.Nil(t, err) {
+			continue
+		}
+
+		counts := make(map[tetris.Tetromino]int)
+		for i := 0; i < 700; i++ {
+			tetromino := randomizer.Next()
+			assert.True(t, tetromino.Valid(), "randomizer %s", name)
+			counts[tetromino]++
+		}
+		assert.Len(t, counts, tetris.TetrominoesCount, "randomizer %s", name)
+	}
+
+	_, err := tetris.NewRandomizer("unknown", rand.New(rand.NewSource(1)))
+	assert.NotNil(t, err)
+}
+
+func TestBagRandomizerDealsEachTetrominoOncePerBag(t *testing.T) {
+	for _, copies := range []int{1, 2} {
+		randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), copies)
+
+		for bag := 0; bag < 10; bag++ {
+			counts := make(map[tetris.Tetromino]int)
+			for i := 0; i < copies*tetris.TetrominoesCount; i++ {
+				counts[randomizer.Next()]++
+			}
+
+			for _, tetromino := range tetris.Tetrominoes() {
+				assert.Equal(t, copies, counts[tetromino], "tetromino %s", tetromino)
+			}
+		}
+	}
+
+	assert.Panics(t, func() { tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 0) })
+}
+
+func TestHistoryRandomizerFirstTetromino(t *testing.T) {
+	for seed := int64(0); seed < 100; seed++ {
+		first := tetris.NewHistoryRandomizer(rand.New(rand.NewSource(seed))).Next()
+		assert.NotContains(t, []tetris.Tetromino{S, Z, O}, first)
+	}
+}
+
+func TestRandomizersWithHistoryRepeatLess(t *testing.T) {
+	repeats := func(randomizer tetris.Randomizer) int {
+		count := 0
+		previous := randomizer.Next()
+		for i := 0; i < 7000; i++ {
+			next := randomizer.Next()
+			if next == previous {
+				count++
+			}
+			previous = next
+		}
+		return count
+	}
+
+	// A uniform randomizer repeats a tetromino once every seven on average.
+	uniform := repeats(tetris.NewUniformRandomizer(rand.New(rand.NewSource(1))))
+	assert.InDelta(t, 1000, uniform, 100)
+
+	assert.True(t, repeats(tetris.NewNESRandomizer(rand.New(rand.NewSource(1)))) < uniform/2)
+	assert.True(t, repeats(tetris.NewHistoryRandomizer(rand.New(rand.NewSource(1)))) < uniform/3)
+}
+
+func TestRandomizerProbabilities(t *testing.T) {
+	const draws = 70000
+
+	for _, name := range tetris.RandomizerNames() {
+		randomizer, _ := tetris.NewRandomizer(name, rand.New(rand.NewSource(1)))
+		_, ok := randomizer.(tetris.Distribution)
+		assert.True(t, ok, "randomizer %s", name)
+
+		// Over many draws, the expected number of each tetromino is close to the number of times it is dealt.
+		var expected, dealt [tetris.TetrominoesCount + 1]float64
+		for i := 0; i < draws; i++ {
+			probabilities := tetris.NextProbabilities(randomizer)
+			assert.Zero(t, probabilities[tetris.TetrominoEmpty])
+
+			var sum float64
+			for tetromino, p := range probabilities {
+				expected[tetromino] += p
+				sum += p
+			}
+			assert.InDelta(t, 1, sum, 1e-9, "randomizer %s", name)
+
+			tetromino := randomizer.Next()
+			assert.True(t, probabilities[tetromino] > 0, "randomizer %s dealt impossible %s", name, tetromino)
+			dealt[tetromino]++
+		}
+
+		for _, tetromino := range tetris.Tetrominoes() {
+			assert.InEpsilon(t, expected[tetromino], dealt[tetromino], 0.03, "randomizer %s, %s", name, tetromino)
+		}
+	}
+}
+
+func TestBagRandomizerProbabilities(t *testing.T) {
+	randomizer := tetris.NewBagRandomizer(rand.New(rand.NewSource(1)), 1)
+
+	dealt := make(map[tetris.Tetromino]bool)
+	for i := 0; i < tetris.TetrominoesCount-1; i++ {
+		dealt[randomizer.Next()] = true
+	}
+
+	// The last tetromino of the bag is certain.
+	probabilities := tetris.NextProbabilities(randomizer)
+	for _, tetromino := range tetris.Tetrominoes() {
+		if dealt[tetromino] {
+			assert.Zero(t, probabilities[tetromino])
+		} else {
+			assert.Equal(t, 1.0, probabilities[tetromino])
+		}
+	}
+}
+
+func TestRegisterRandomizer(t *testing.T) {
+	// The registered randomizer behaves like the uniform one, so that it passes the tests of every randomizer.
+	tetris.RegisterRandomizer("registered", tetris.NewUniformRandomizer)
+	assert.Contains(t, tetris.RandomizerNames(), "registered")
+
+	randomizer, err := tetris.NewRandomizer("registered", rand.New(rand.NewSource(1)))
+	assert.Nil(t, err)
+	assert.True(t, randomizer.Next().Valid())
+
+	assert.Panics(t, func() { tetris.RegisterRandomizer("registered", tetris.NewUniformRandomizer) })
+	assert.Panics(t, func() { tetris.RegisterRandomizer(tetris.Randomizer7Bag, tetris.NewUniformRandomizer) })
+}
@@ -0,0 +1,215 @@
+package tetris
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Scoring follows the Tetris guideline, see https://tetris.wiki/Scoring.
+const (
+	// linesPerLevel is the number of lines that need to be cleared to advance to the next level.
+	linesPerLevel = 10
+
+	// softDropPoints and hardDropPoints are awarded for each row a piece is soft or hard dropped by.
+	softDropPoints = 1
+	hardDropPoints = 2
+)
+
+// lineClearPoints is the number of points awarded for clearing the given number of lines at once
+// with each kind of T-spin, on level 1. On higher levels, the points are multiplied by the level.
+var lineClearPoints = [][]int{
+	TSpinNone: {0, 100, 300, 500, 800},
+	TSpinMini: {100, 200, 400},
+	TSpinFull: {400, 800, 1200, 1600},
+}
+
+// Bonuses awarded for line clears on level 1. On higher levels, they are multiplied by the level.
+const (
+	// comboPoints are awarded for each line clear in a row after the first one.
+	comboPoints = 50
+
+	// backToBackPercent is the percent of the line clear points that a back-to-back difficult line clear is worth.
+	backToBackPercent = 150
+)
+
+// perfectClearPoints is the number of points awarded for clearing the given number of lines at once
+// and leaving the board empty, on level 1. backToBackPerfectClearPoints is awarded instead
+// for a back-to-back perfect clear of four lines.
+var (
+	perfectClearPoints           = []int{0, 800, 1200, 1800, 2000}
+	backToBackPerfectClearPoints = 3200
+)
+
+// LineClear describes the rows cleared by locking a tetromino.
+type LineClear struct {
+	// Lines is the number of rows cleared at once, from 0 to 4.
+	Lines int
+
+	// TSpin is the kind of T-spin the tetromino was locked with.
+	TSpin TSpin
+
+	// Combo is the number of line clears in a row before this one,
+	// or -1 if no rows are cleared and so the combo is broken.
+	Combo int
+
+	// BackToBack is true if the line clear is difficult, see LineClear.Difficult,
+	// and it follows another difficult line clear with no other line clears between them.
+	BackToBack bool
+
+	// PerfectClear is true if the line clear leaves the board empty.
+	PerfectClear bool
+}
+
+// Difficult returns true if the line clear is a tetris or a T-spin (including mini ones) that clears rows.
+// Difficult line clears in a row are worth a back-to-back bonus.
+func (c LineClear) Difficult() bool {
+	return c.Lines == 4 || c.Lines > 0 && c.TSpin != TSpinNone
+}
+
+// Attack returns the number of garbage lines the line clear sends to an opponent in multiplayer games.
+// It follows the guideline attack table, see https://tetris.wiki/Garbage, with 10 lines for perfect clears.
+func (c LineClear) Attack() int {
+	if c.Lines == 0 {
+		return 0
+	}
+
+	attack := lineClearAttack[c.TSpin][c.Lines]
+	if c.BackToBack {
+		attack++
+	}
+	if c.Combo < len(comboAttack) {
+		attack += comboAttack[c.Combo]
+	} else {
+		attack += comboAttack[len(comboAttack)-1]
+	}
+	if c.PerfectClear {
+		attack += perfectClearAttack
+	}
+
+	return attack
+}
+
+// lineClearAttack is the number of garbage lines sent for clearing the given number of lines at once
+// with each kind of T-spin. comboAttack is the number of lines added for the given combo
+// and perfectClearAttack for a perfect clear.
+var (
+	lineClearAttack = [][]int{
+		TSpinNone: {0, 0, 1, 2, 4},
+		TSpinMini: {0, 0, 1},
+		TSpinFull: {0, 2, 4, 6},
+	}
+	comboAttack        = []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5}
+	perfectClearAttack = 10
+)
+
+// Score returns the number of points scored in the board's game.
+func (b *Board) Score() int {
+	return b.score
+}
+
+// Level returns the current level of the board's game.
+// The game starts at level 1 and advances a level every 10 cleared lines.
+func (b *Board) Level() int {
+	return 1 + b.clearedLines/linesPerLevel
+}
+
+// Gravity returns how long it takes a piece to fall by one row on the current level of the board's game.
+func (b *Board) Gravity() time.Duration {
+	return Gravity(b.Level())
+}
+
+// Gravity returns how long it takes a piece to fall by one row on the given level.
+// Levels above 20 are as fast as level 20.
+// Gravity panics if the level is not positive.
+func Gravity(level int) time.Duration {
+	if level < 1 {
+		panic(fmt.Errorf("Gravity: invalid level %d provided", level))
+	}
+
+	if level > 20 {
+		level = 20
+	}
+
+	seconds := math.Pow(0.8-float64(level-1)*0.007, float64(level-1))
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// SoftDrop returns the piece moved one row down, like MoveDown, and awards the points for soft dropping it.
+// If the piece can not be moved, it is returned unchanged with false.
+func (b *Board) SoftDrop(piece Piece) (Piece, bool) {
+	moved, ok := b.MoveDown(piece)
+	if ok {
+		b.score += softDropPoints
+	}
+	return moved, ok
+}
+
+// LastClear returns the line clear that resulted from locking the last tetromino.
+// The line clear is also reported when no rows are cleared, as T-spins score points on their own.
+func (b *Board) LastClear() LineClear {
+	return b.lastClear
+}
+
+// Combo returns the number of line clears in a row before the last one,
+// or -1 if the last tetromino did not clear any rows.
+func (b *Board) Combo() int {
+	return b.combo - 1
+}
+
+// BackToBack returns true if the last line clear was difficult, see LineClear.Difficult,
+// so the next difficult line clear will be back-to-back.
+func (b *Board) BackToBack() bool {
+	return b.backToBack
+}
+
+// lineClear returns the line clear of the given number of lines with the given T-spin
+// and updates the combo and back-to-back state of the board.
+// The full rows are expected to be already cleared.
+func (b *Board) lineClear(lines int, spin TSpin) LineClear {
+	clear := LineClear{Lines: lines, TSpin: spin}
+
+	if lines == 0 {
+		b.combo = 0
+		clear.Combo = -1
+		return clear
+	}
+
+	clear.Combo = b.combo
+	b.combo++
+
+	clear.BackToBack = b.backToBack && clear.Difficult()
+	b.backToBack = clear.Difficult()
+
+	clear.PerfectClear = true
+	for _, row := range b.rows {
+		if row != 0 {
+			clear.PerfectClear = false
+			break
+		}
+	}
+
+	return clear
+}
+
+// scoreLineClear awards the points for the given line clear, on the given level.
+func (b *Board) scoreLineClear(clear LineClear, level int) {
+	points := lineClearPoints[clear.TSpin][clear.Lines]
+	if clear.BackToBack {
+		points = points * backToBackPercent / 100
+	}
+
+	if clear.Combo > 0 {
+		points += comboPoints * clear.Combo
+	}
+
+	if clear.PerfectClear {
+		if clear.BackToBack && clear.Lines == 4 {
+			points += backToBackPerfectClearPoints
+		} else {
+			points += perfectClearPoints[clear.Lines]
+		}
+	}
+
+	b.score += points * level
+}
@@ -0,0 +1,187 @@
+package tetris_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardScoreForLineClears(t *testing.T) {
+	tests := []struct {
+		lines int
+		score int
+	}{
+		{lines: 1, score: 100},
+		{lines: 2, score: 300},
+		{lines: 3, score: 500},
+		// Clearing four lines also leaves the board empty.
+		{lines: 4, score: 800 + 2000},
+	}
+
+	for _, test := range tests {
+		// Vertical I pieces in the first three columns overlap the one in the last column by the given number of rows.
+		board := tetris.NewBoardWithSize(4, 20)
+		board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 16, Col: 1})
+		for col := 0; col < 3; col++ {
+			board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 12 + test.lines, Col: col - 2})
+		}
+
+		assert.Equal(t, test.lines, board.ClearedLines())
+		assert.Equal(t, test.score, board.Score(), "%d lines", test.lines)
+	}
+}
+
+func TestBoardScoreForCombos(t *testing.T) {
+	// The O in the bottom left corner is never cleared, so no line clear is perfect.
+	board := tetris.NewBoardWithSize(4, 20)
+	board.Lock(tetris.Piece{Tetromino: O, Row: 18, Col: 0})
+	assert.Equal(t, -1, board.Combo())
+
+	scores := []int{100, 100 + 50, 100 + 100, 100 + 150}
+	for combo, score := range scores {
+		before := board.Score()
+		clear := board.Lock(tetris.Piece{Tetromino: I, Row: 16, Col: 0})
+
+		assert.Equal(t, tetris.LineClear{Lines: 1, Combo: combo}, clear)
+		assert.Equal(t, combo, board.Combo())
+		assert.Equal(t, score, board.Score()-before)
+	}
+
+	board.Lock(tetris.Piece{Tetromino: O, Row: 16, Col: 2})
+	assert.Equal(t, -1, board.Combo())
+	assert.Equal(t, -1, board.LastClear().Combo)
+}
+
+func TestBoardScoreForBackToBack(t *testing.T) {
+	// The last column is filled with vertical I pieces, so each group of four of them in the other columns clears four lines.
+	board := tetris.NewBoardWithSize(5, 20)
+	for row := 8; row < 20; row += 4 {
+		board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: row, Col: 2})
+	}
+
+	tests := []struct {
+		clear  tetris.LineClear
+		score  int
+		attack int
+	}{
+		{
+			clear:  tetris.LineClear{Lines: 4},
+			score:  800,
+			attack: 4,
+		},
+		{
+			clear:  tetris.LineClear{Lines: 4, BackToBack: true},
+			score:  1200,
+			attack: 5,
+		},
+		{
+			clear:  tetris.LineClear{Lines: 4, BackToBack: true, PerfectClear: true},
+			score:  1200 + 3200,
+			attack: 4 + 1 + 10,
+		},
+	}
+
+	for _, test := range tests {
+		before := board.Score()
+
+		var clear tetris.LineClear
+		for col := 0; col < 4; col++ {
+			clear = board.Lock(tetris.Piece{Tetromino: I, Orientation: tetris.OrientationRight, Row: 16, Col: col - 2})
+		}
+
+		assert.Equal(t, test.clear, clear)
+		assert.True(t, clear.Difficult())
+		assert.True(t, board.BackToBack())
+		assert.Equal(t, test.score, board.Score()-before)
+		assert.Equal(t, test.attack, clear.Attack())
+	}
+}
+
+func TestLineClearAttack(t *testing.T) {
+	tests := []struct {
+		clear  tetris.LineClear
+		attack int
+	}{
+		{clear: tetris.LineClear{Lines: 0, TSpin: tetris.TSpinFull, Combo: -1}, attack: 0},
+		{clear: tetris.LineClear{Lines: 1}, attack: 0},
+		{clear: tetris.LineClear{Lines: 2}, attack: 1},
+		{clear: tetris.LineClear{Lines: 3, Combo: 4}, attack: 2 + 2},
+		{clear: tetris.LineClear{Lines: 2, TSpin: tetris.TSpinMini}, attack: 1},
+		{clear: tetris.LineClear{Lines: 2, TSpin: tetris.TSpinFull, BackToBack: true}, attack: 4 + 1},
+		{clear: tetris.LineClear{Lines: 1, Combo: 20}, attack: 5},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.attack, test.clear.Attack(), "%+v", test.clear)
+	}
+}
+
+func TestBoardScoreForDrops(t *testing.T) {
+	board := tetris.NewBoard()
+
+	piece, ok := board.SoftDrop(board.SpawnPiece(O))
+	assert.True(t, ok)
+	assert.Equal(t, 1, board.Score())
+
+	board.HardDrop(piece)
+	assert.Equal(t, 1+2*17, board.Score())
+
+	board.Drop(O, 0, 0)
+	assert.Equal(t, 1+2*17+2*18, board.Score())
+
+	_, ok = board.SoftDrop(board.Ghost(board.SpawnPiece(O)))
+	assert.False(t, ok)
+	assert.Equal(t, 1+2*17+2*18, board.Score())
+}
+
+func TestBoardPlaceScoresLikeHardDrop(t *testing.T) {
+	for _, tetromino := range tetris.Tetrominoes() {
+		human, placed := tetris.NewBoard(), tetris.NewBoard()
+		for i := 0; i < 3; i++ {
+			// The human player shifts the piece at the top of the board and hard drops it,
+			// while the same placement is placed directly.
+			piece := human.SpawnPiece(tetromino)
+			for shift := 0; shift < i; shift++ {
+				piece, _ = human.MoveRight(piece)
+			}
+			human.HardDrop(piece)
+			placed.Place(placed.Ghost(piece))
+
+			assert.Equal(t, human.Score(), placed.Score(), "tetromino %s, piece %d", tetromino, i)
+		}
+		assert.True(t, placed.Score() > 0)
+	}
+}
+
+func TestBoardLevel(t *testing.T) {
+	board := tetris.NewBoardWithSize(4, 20)
+	board.Lock(tetris.Piece{Tetromino: O, Row: 18, Col: 0})
+	assert.Equal(t, 1, board.Level())
+
+	for i := 0; i < 9; i++ {
+		board.Lock(tetris.Piece{Tetromino: I, Row: 16, Col: 0})
+	}
+	assert.Equal(t, 1, board.Level())
+
+	// The tenth line is scored on level 1, the next ones on level 2.
+	board.Lock(tetris.Piece{Tetromino: I, Row: 16, Col: 0})
+	assert.Equal(t, 2, board.Level())
+	assert.Equal(t, tetris.Gravity(2), board.Gravity())
+
+	board.Lock(tetris.Piece{Tetromino: O, Row: 16, Col: 2})
+	before := board.Score()
+	board.Lock(tetris.Piece{Tetromino: I, Row: 14, Col: 0})
+	assert.Equal(t, 2*100, board.Score()-before)
+}
+
+func TestGravity(t *testing.T) {
+	assert.Equal(t, time.Second, tetris.Gravity(1))
+	assert.InDelta(t, 793*time.Millisecond, tetris.Gravity(2), float64(time.Millisecond))
+	assert.InDelta(t, 64*time.Millisecond, tetris.Gravity(10), float64(time.Millisecond))
+	assert.Equal(t, tetris.Gravity(20), tetris.Gravity(25))
+	assert.True(t, tetris.Gravity(15) < tetris.Gravity(14))
+
+	assert.Panics(t, func() { tetris.Gravity(0) })
+}
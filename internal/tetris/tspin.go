@@ -0,0 +1,78 @@
+package tetris
+
+import "fmt"
+
+// TSpin is the kind of T-spin with which a piece has been locked.
+// See https://tetris.wiki/T-Spin.
+type TSpin int
+
+// The kinds of T-spins, from least to most valuable.
+const (
+	TSpinNone TSpin = iota
+	TSpinMini
+	TSpinFull
+)
+
+// tSpinsCount is the number of kinds of T-spins, including TSpinNone.
+const tSpinsCount = 3
+
+// TSpin implements Stringer.
+func (t TSpin) String() string {
+	switch t {
+	case TSpinNone:
+		return "none"
+	case TSpinMini:
+		return "mini T-spin"
+	case TSpinFull:
+		return "T-spin"
+	default:
+		panic(fmt.Errorf("TSpin.String: invalid T-spin %d provided", t))
+	}
+}
+
+// tSpinCorners are the corners of the bounding box of the T tetromino, relative to its top left cell.
+// tSpinFrontCorners contains for each orientation the indices of the two corners next to the side that T points to.
+var (
+	tSpinCorners      = [4]Cell{{0, 0}, {0, 2}, {2, 0}, {2, 2}}
+	tSpinFrontCorners = [orientationsCount][2]int{
+		OrientationSpawn: {0, 1},
+		OrientationRight: {1, 3},
+		OrientationTwo:   {2, 3},
+		OrientationLeft:  {0, 2},
+	}
+)
+
+// tSpinKick is the index of the SRS kick that always results in a full T-spin,
+// even if the piece's front corners are not both occupied.
+const tSpinKick = 4
+
+// tSpin returns the kind of T-spin performed by rotating a piece into the given position with the kick with the given index.
+// It uses the 3-corner rule: at least three corners of the T's bounding box must be occupied or outside the board.
+// The T-spin is a mini one, unless both corners next to the side that T points to are occupied
+// or the piece has been kicked with the last kick of a 90 degree rotation.
+func (b *Board) tSpin(piece Piece, turn Turn, kick int) TSpin {
+	if piece.Tetromino != TetrominoT {
+		return TSpinNone
+	}
+
+	var occupied [len(tSpinCorners)]bool
+	count := 0
+	for i, corner := range tSpinCorners {
+		row, col := piece.Row+corner.Row, piece.Col+corner.Col
+		occupied[i] = !b.isValidCell(row, col) || b.rows[row]&(1<<uint(col)) != 0
+		if occupied[i] {
+			count++
+		}
+	}
+
+	if count < 3 {
+		return TSpinNone
+	}
+
+	front := tSpinFrontCorners[piece.Orientation]
+	if occupied[front[0]] && occupied[front[1]] || turn != Turn180 && kick == tSpinKick {
+		return TSpinFull
+	}
+
+	return TSpinMini
+}
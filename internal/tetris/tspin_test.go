@@ -0,0 +1,81 @@
+package tetris_test
+
+import (
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/stretchr/testify/assert"
+)
+
+// findPlacement returns the placement of the tetromino on the board that occupies the given cells.
+func findPlacement(board *tetris.Board, tetromino tetris.Tetromino, cells [4]tetris.Cell) (tetris.Placement, bool) {
+	for _, placement := range board.Placements(tetromino) {
+		if placement.Piece.Cells() == cells {
+			return placement, true
+		}
+	}
+	return tetris.Placement{}, false
+}
+
+func TestBoardLockTSpinDouble(t *testing.T) {
+	// Row 3: X..XX
+	// Row 4: X...X
+	// Row 5: XX.XX
+	// Row 6: X..XX
+	// Row 7: XXX.X
+	board := tetris.NewBoardWithSize(5, 8)
+	board.Lock(tetris.Piece{Tetromino: S, Orientation: tetris.OrientationLeft, Row: 5, Col: 3})
+	board.Lock(tetris.Piece{Tetromino: J, Row: 6, Col: 0})
+	board.Lock(tetris.Piece{Tetromino: L, Orientation: tetris.OrientationLeft, Row: 3, Col: 3})
+	board.Lock(tetris.Piece{Tetromino: L, Orientation: tetris.OrientationRight, Row: 3, Col: -1})
+
+	placement, ok := findPlacement(board, T, cells(4, 1, 4, 2, 4, 3, 5, 2))
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, tetris.TSpinFull, placement.Piece.TSpin())
+	assert.Equal(t, tetris.MoveRotateCounterClockwise, placement.Moves[len(placement.Moves)-1])
+
+	clear := board.Lock(placement.Piece)
+	assert.Equal(t, tetris.LineClear{Lines: 2, TSpin: tetris.TSpinFull}, clear)
+	assert.Equal(t, clear, board.LastClear())
+	assert.Equal(t, 1200, board.Score())
+}
+
+func TestBoardLockMiniTSpin(t *testing.T) {
+	// Row 4: .XX..
+	// Row 5: .XXX.
+	// Row 6: ..XX.
+	// Row 7: ..X..
+	board := tetris.NewBoardWithSize(5, 8)
+	board.Lock(tetris.Piece{Tetromino: Z, Orientation: tetris.OrientationLeft, Row: 5, Col: 2})
+	board.Lock(tetris.Piece{Tetromino: O, Row: 4, Col: 1})
+
+	placement, ok := findPlacement(board, T, cells(2, 0, 3, 0, 3, 1, 4, 0))
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, tetris.TSpinMini, placement.Piece.TSpin())
+
+	clear := board.Lock(placement.Piece)
+	assert.Equal(t, tetris.LineClear{Lines: 0, TSpin: tetris.TSpinMini, Combo: -1}, clear)
+	assert.Equal(t, 100, board.Score())
+
+	// Tetrominoes dropped straight down are never T-spins.
+	assert.Nil(t, board.Drop(O, 0, 3))
+	assert.Equal(t, tetris.LineClear{Lines: 1, TSpin: tetris.TSpinNone}, board.LastClear())
+}
+
+func TestBoardMoveCancelsTSpin(t *testing.T) {
+	board := tetris.NewBoardWithSize(5, 8)
+	board.Lock(tetris.Piece{Tetromino: Z, Orientation: tetris.OrientationLeft, Row: 5, Col: 2})
+	board.Lock(tetris.Piece{Tetromino: O, Row: 4, Col: 1})
+
+	// The T is spun into its slot from one row above and then moved down into it.
+	piece, ok := board.Rotate(tetris.Piece{Tetromino: T, Row: 1, Col: -1}, tetris.TurnClockwise)
+	assert.True(t, ok)
+	piece, ok = board.MoveDown(piece)
+	assert.True(t, ok)
+	assert.Equal(t, cells(2, 0, 3, 0, 3, 1, 4, 0), piece.Cells())
+	assert.Equal(t, tetris.TSpinNone, piece.TSpin())
+}
@@ -0,0 +1,67 @@
+package tune
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+)
+
+// initialMean and initialVariance return the distribution of each weight in the first generation.
+func initialMean(config Config) []float64 {
+	return make([]float64, len(config.Features))
+}
+
+func initialVariance(config Config) []float64 {
+	variance := make([]float64, len(config.Features))
+	for i := range variance {
+		variance[i] = config.InitialVariance
+	}
+	return variance
+}
+
+// sample returns weight vectors of the configured population,
+// whose weights are independently normally distributed with the given means and variances.
+// Each vector is normalized, like in the genetic algorithm, so that evaluations of boards stay in range
+// however large the variance is.
+func sample(rng *rand.Rand, config Config, mean, variance []float64) [][]float64 {
+	vectors := make([][]float64, config.Population)
+	for i := range vectors {
+		vectors[i] = make([]float64, len(mean))
+		for j := range vectors[i] {
+			vectors[i][j] = mean[j] + rng.NormFloat64()*math.Sqrt(variance[j])
+		}
+		normalize(vectors[i])
+	}
+	return vectors
+}
+
+// refit sets the mean and variance of each weight of the evaluated generation
+// to those of its configured elite fraction, adding the generation's noise to the variance.
+func refit(config Config, generation *Generation) {
+	elite := int(math.Round(config.EliteFraction * float64(len(generation.Population))))
+	if elite < 1 {
+		elite = 1
+	}
+
+	noise := math.Max(config.Noise-config.NoiseDecay*float64(generation.Number), 0)
+
+	generation.Mean = ai.Weights{}
+	generation.Variance = ai.Weights{}
+	for _, name := range config.Features {
+		var mean float64
+		for _, individual := range generation.Population[:elite] {
+			mean += individual.Weights[name]
+		}
+		mean /= float64(elite)
+
+		var variance float64
+		for _, individual := range generation.Population[:elite] {
+			variance += (individual.Weights[name] - mean) * (individual.Weights[name] - mean)
+		}
+		variance /= float64(elite)
+
+		generation.Mean[name] = mean
+		generation.Variance[name] = variance + noise
+	}
+}
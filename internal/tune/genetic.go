@@ -0,0 +1,85 @@
+package tune
+
+import (
+	"math"
+	"math/rand"
+)
+
+// randomPopulation returns weight vectors of the configured population, with random weights in [-1; 1].
+// The weights of the vector are the weights of the configured features, in order.
+func randomPopulation(rng *rand.Rand, config Config) [][]float64 {
+	vectors := make([][]float64, config.Population)
+	for i := range vectors {
+		vectors[i] = make([]float64, len(config.Features))
+		for j := range vectors[i] {
+			vectors[i][j] = 2*rng.Float64() - 1
+		}
+		normalize(vectors[i])
+	}
+	return vectors
+}
+
+// breed returns the weight vectors of the population that follows the given generation.
+func breed(rng *rand.Rand, config Config, generation Generation) [][]float64 {
+	vectors := make([][]float64, 0, config.Population)
+	for _, elite := range generation.Population[:config.Elite] {
+		vectors = append(vectors, vector(config, elite.Weights))
+	}
+
+	tournament := func() Individual {
+		best := generation.Population[rng.Intn(len(generation.Population))]
+		for i := 1; i < config.TournamentSize; i++ {
+			if other := generation.Population[rng.Intn(len(generation.Population))]; other.Fitness > best.Fitness {
+				best = other
+			}
+		}
+		return best
+	}
+
+	for len(vectors) < config.Population {
+		first, second := tournament(), tournament()
+		child := crossover(vector(config, first.Weights), first.Fitness, vector(config, second.Weights), second.Fitness)
+
+		for i := range child {
+			if rng.Float64() < config.MutationRate {
+				child[i] += rng.NormFloat64() * config.MutationScale
+			}
+		}
+		normalize(child)
+
+		vectors = append(vectors, child)
+	}
+
+	return vectors
+}
+
+// crossover returns the average of the given weight vectors, weighted by their fitness.
+// If neither of them is fit, their plain average is returned.
+func crossover(first []float64, firstFitness float64, second []float64, secondFitness float64) []float64 {
+	if firstFitness+secondFitness <= 0 {
+		firstFitness, secondFitness = 1, 1
+	}
+
+	child := make([]float64, len(first))
+	for i := range child {
+		child[i] = (first[i]*firstFitness + second[i]*secondFitness) / (firstFitness + secondFitness)
+	}
+	return child
+}
+
+// normalize scales the weight vector to unit length, which does not change the moves the AI makes with it.
+// A zero vector is left unchanged.
+func normalize(v []float64) {
+	var length float64
+	for _, w := range v {
+		length += w * w
+	}
+	length = math.Sqrt(length)
+
+	if length == 0 {
+		return
+	}
+	for i := range v {
+		v[i] /= length
+	}
+}
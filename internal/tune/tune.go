@@ -0,0 +1,440 @@
+// Package tune tunes the weights of the AI's features with a genetic algorithm
+// or with the noisy cross-entropy method.
+//
+// Each individual of the population is a weight vector, whose fitness is the average number of lines
+// the AI clears with it in a number of seeded games.
+//
+// With the genetic algorithm (https://en.wikipedia.org/wiki/Genetic_algorithm), the fittest individuals
+// of every generation are kept and the rest are replaced by children of parents chosen by tournament selection.
+// A child is a crossover of its parents, the average of their weight vectors weighted by their fitness,
+// with some of its weights mutated.
+//
+// With the noisy cross-entropy method, as used by Szita and Lőrincz in "Learning Tetris Using the Noisy
+// Cross-Entropy Method", every generation is sampled from a normal distribution of each weight,
+// whose mean and variance are then refitted to the fittest fraction of the generation.
+// Noise is added to the variance, so that the distribution does not converge too early.
+package tune
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ozhi/tetris-ai/internal/ai"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+)
+
+// Names of the optimizers, see Config.Method.
+const (
+	MethodGenetic      = "genetic"
+	MethodCrossEntropy = "cross-entropy"
+)
+
+// MethodNames returns the names of all optimizers.
+func MethodNames() []string {
+	return []string{MethodGenetic, MethodCrossEntropy}
+}
+
+// Config configures the optimizer and the games with which the individuals are evaluated.
+type Config struct {
+	// Method is the name of the optimizer, MethodGenetic or MethodCrossEntropy.
+	Method string
+
+	// Features are the names of the features whose weights are tuned, see ai.FeatureNames.
+	Features []string
+
+	// Population is the number of individuals in each generation and Generations is the number of generations.
+	Population  int
+	Generations int
+
+	// The rest of the options of the genetic algorithm are:
+
+	// Elite is the number of fittest individuals that are kept in the next generation unchanged.
+	Elite int
+
+	// TournamentSize is the number of random individuals out of which the fittest is chosen as a parent.
+	TournamentSize int
+
+	// MutationRate is the probability with which each weight of a child is mutated
+	// and MutationScale is the standard deviation of the normally distributed number added to it.
+	MutationRate  float64
+	MutationScale float64
+
+	// The rest of the options of the cross-entropy method are:
+
+	// EliteFraction is the fraction of the fittest individuals of a generation to which the distribution is refitted.
+	EliteFraction float64
+
+	// InitialVariance is the variance of each weight in the first generation, whose mean weights are 0.
+	InitialVariance float64
+
+	// Noise is added to the variance of each weight refitted after the first generation.
+	// It decreases by NoiseDecay every generation, down to 0. Noise 0 disables it and NoiseDecay 0 keeps it constant.
+	Noise      float64
+	NoiseDecay float64
+
+	// The rest of the options are shared by both optimizers:
+
+	// Games is the number of games each individual plays in each generation.
+	// Every individual of a generation plays the same games, but each generation plays different ones.
+	// A game ends after MaxTetrominoes tetrominoes, so that good individuals do not play forever.
+	Games          int
+	MaxTetrominoes int
+
+	// Width and Height are the size of the boards and Randomizer is the name of the randomizer
+	// of the games, see tetris.RandomizerNames.
+	Width      int
+	Height     int
+	Randomizer string
+
+	// Seed seeds the genetic algorithm and the games, so that tuning with a given seed can be repeated.
+	Seed int64
+
+	// Workers is the number of games played in parallel.
+	Workers int
+
+	// Checkpoint is the path of the file to which each generation is saved, see Generation.
+	// If it is empty, the generations are not saved.
+	// If Resume is true and the file exists, tuning continues after the generation saved in it.
+	Checkpoint string
+	Resume     bool
+}
+
+// DefaultConfig returns a configuration that tunes the features of ai.DefaultWeights with the genetic algorithm
+// in reasonable time. The options of the cross-entropy method follow Szita and Lőrincz, with constant noise,
+// but as the sampled weights are normalized, the variance and noise are scaled down a hundred times
+// to fit weights of unit length.
+func DefaultConfig() Config {
+	var features []string
+	for name := range ai.DefaultWeights() {
+		features = append(features, name)
+	}
+	sort.Strings(features)
+
+	return Config{
+		Method:          MethodGenetic,
+		Features:        features,
+		Population:      50,
+		Generations:     20,
+		Elite:           5,
+		TournamentSize:  5,
+		MutationRate:    0.1,
+		MutationScale:   0.2,
+		EliteFraction:   0.1,
+		InitialVariance: 1,
+		Noise:           0.04,
+		Games:           10,
+		MaxTetrominoes:  500,
+		Width:           tetris.DefaultBoardWidth,
+		Height:          tetris.DefaultBoardHeight,
+		Randomizer:      tetris.RandomizerUniform,
+		Seed:            1,
+		Workers:         runtime.NumCPU(),
+	}
+}
+
+// validate returns error if the configuration can not be used for tuning.
+func (c Config) validate() error {
+	if len(c.Features) == 0 {
+		return fmt.Errorf("no features to tune")
+	}
+	weights := ai.Weights{}
+	for _, name := range c.Features {
+		weights[name] = 1
+	}
+	if _, err := ai.NewEvaluator(weights); err != nil {
+		return err
+	}
+
+	if err := tetris.ValidateBoardSize(c.Width, c.Height); err != nil {
+		return err
+	}
+
+	if _, err := tetris.NewRandomizer(c.Randomizer, rand.New(rand.NewSource(c.Seed))); err != nil {
+		return err
+	}
+
+	switch c.Method {
+	case MethodGenetic:
+		switch {
+		case c.Elite < 0 || c.Elite >= c.Population:
+			return fmt.Errorf("invalid elite %d, expected less than the population", c.Elite)
+		case c.TournamentSize < 1 || c.TournamentSize > c.Population:
+			return fmt.Errorf("invalid tournament size %d, expected at most the population", c.TournamentSize)
+		case c.MutationRate < 0 || c.MutationRate > 1:
+			return fmt.Errorf("invalid mutation rate %f, expected a probability", c.MutationRate)
+		case c.MutationScale < 0:
+			return fmt.Errorf("invalid mutation scale %f", c.MutationScale)
+		}
+
+	case MethodCrossEntropy:
+		switch {
+		case c.EliteFraction <= 0 || c.EliteFraction > 1:
+			return fmt.Errorf("invalid elite fraction %f", c.EliteFraction)
+		case c.InitialVariance <= 0:
+			return fmt.Errorf("invalid initial variance %f", c.InitialVariance)
+		case c.Noise < 0 || c.NoiseDecay < 0:
+			return fmt.Errorf("invalid noise %f or noise decay %f", c.Noise, c.NoiseDecay)
+		}
+
+	default:
+		return fmt.Errorf("unknown method %q, expected one of: %s", c.Method, strings.Join(MethodNames(), ", "))
+	}
+
+	switch {
+	case c.Population < 2:
+		return fmt.Errorf("invalid population %d, at least 2 individuals are needed", c.Population)
+	case c.Generations < 1:
+		return fmt.Errorf("invalid number of generations %d", c.Generations)
+	case c.Games < 1 || c.MaxTetrominoes < 1:
+		return fmt.Errorf("invalid number of games %d or tetrominoes %d", c.Games, c.MaxTetrominoes)
+	case c.Workers < 1:
+		return fmt.Errorf("invalid number of workers %d", c.Workers)
+	}
+
+	return nil
+}
+
+// Individual is a weight vector and its fitness: the average number of lines cleared with it.
+type Individual struct {
+	Weights ai.Weights `json:"weights"`
+	Fitness float64    `json:"fitness"`
+}
+
+// Generation is a population of individuals, sorted from the fittest, and its number, starting from 0.
+// Generations are saved to checkpoint files as JSON.
+type Generation struct {
+	Number     int          `json:"generation"`
+	Population []Individual `json:"population"`
+
+	// Mean and Variance are the distribution of each weight refitted to the generation's elite, including the noise,
+	// from which the cross-entropy method samples the next generation. They are nil for the genetic algorithm.
+	Mean     ai.Weights `json:"mean,omitempty"`
+	Variance ai.Weights `json:"variance,omitempty"`
+}
+
+// Best returns the fittest individual of the generation.
+func (g Generation) Best() Individual {
+	return g.Population[0]
+}
+
+// Run tunes the weights with the given configuration and returns the fittest individual of the last generation.
+// With the cross-entropy method, the mean weights of the last generation are often better than the fittest individual,
+// see Generation.Mean.
+// After each generation is evaluated, it is saved to the checkpoint file and passed to report, if it is not nil.
+// Run returns error if the configuration is invalid or the checkpoint file can not be read or written.
+func Run(config Config, report func(Generation)) (Individual, error) {
+	if err := config.validate(); err != nil {
+		return Individual{}, fmt.Errorf("tune.Run: invalid configuration: %s", err)
+	}
+
+	var (
+		generation Generation
+		next       = 0
+	)
+	if config.Resume && config.Checkpoint != "" {
+		loaded, err := load(config.Checkpoint)
+		if err != nil && !os.IsNotExist(err) {
+			return Individual{}, fmt.Errorf("tune.Run: could not resume: %s", err)
+		}
+		if err == nil && config.Method == MethodGenetic && len(loaded.Population) <= config.Elite {
+			return Individual{}, fmt.Errorf("tune.Run: could not resume: checkpoint has fewer individuals than the elite")
+		}
+		if err == nil && config.Method == MethodCrossEntropy && (loaded.Mean == nil || loaded.Variance == nil) {
+			return Individual{}, fmt.Errorf("tune.Run: could not resume: checkpoint has no distribution of the weights")
+		}
+		if err == nil {
+			generation = loaded
+			next = generation.Number + 1
+		}
+	}
+
+	for ; next < config.Generations; next++ {
+		// Each generation has its own source of random numbers, so that resumed tuning continues the same way.
+		rng := rand.New(rand.NewSource(config.Seed + int64(next)))
+
+		var vectors [][]float64
+		switch {
+		case config.Method == MethodCrossEntropy && next == 0:
+			vectors = sample(rng, config, initialMean(config), initialVariance(config))
+		case config.Method == MethodCrossEntropy:
+			vectors = sample(rng, config, vector(config, generation.Mean), vector(config, generation.Variance))
+		case next == 0:
+			vectors = randomPopulation(rng, config)
+		default:
+			vectors = breed(rng, config, generation)
+		}
+
+		seeds := make([]int64, config.Games)
+		for i := range seeds {
+			seeds[i] = rng.Int63()
+		}
+
+		var err error
+		generation, err = evaluate(config, next, vectors, seeds)
+		if err != nil {
+			return Individual{}, fmt.Errorf("tune.Run: %s", err)
+		}
+		if config.Method == MethodCrossEntropy {
+			refit(config, &generation)
+		}
+
+		if config.Checkpoint != "" {
+			if err := save(config.Checkpoint, generation); err != nil {
+				return Individual{}, fmt.Errorf("tune.Run: could not save checkpoint: %s", err)
+			}
+		}
+
+		if report != nil {
+			report(generation)
+		}
+	}
+
+	return generation.Best(), nil
+}
+
+// vector returns the weights of the configured features, in order.
+func vector(config Config, weights ai.Weights) []float64 {
+	v := make([]float64, len(config.Features))
+	for i, name := range config.Features {
+		v[i] = weights[name]
+	}
+	return v
+}
+
+// evaluate returns the generation with the given number and weight vectors,
+// whose fitness is evaluated by playing the games with the given seeds.
+// The games are played in parallel by the configured number of workers.
+// evaluate returns error if an evaluator can not be created with the weights of a vector.
+func evaluate(config Config, number int, vectors [][]float64, seeds []int64) (Generation, error) {
+	generation := Generation{
+		Number:     number,
+		Population: make([]Individual, len(vectors)),
+	}
+
+	evaluators := make([]*ai.Evaluator, len(vectors))
+	for i, v := range vectors {
+		weights := ai.Weights{}
+		for j, name := range config.Features {
+			weights[name] = v[j]
+		}
+		generation.Population[i].Weights = weights
+
+		evaluator, err := ai.NewEvaluator(weights)
+		if err != nil {
+			return Generation{}, fmt.Errorf("could not evaluate individual %d: %s", i, err)
+		}
+		evaluators[i] = evaluator
+	}
+
+	type job struct {
+		individual int
+		game       int
+	}
+
+	var (
+		jobs  = make(chan job)
+		lines = make([][]int, len(vectors))
+		wg    sync.WaitGroup
+	)
+	for i := range lines {
+		lines[i] = make([]int, len(seeds))
+	}
+
+	for w := 0; w < config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// Each job writes to its own element, so no locking is needed.
+				lines[job.individual][job.game] = play(config, evaluators[job.individual], seeds[job.game])
+			}
+		}()
+	}
+
+	for i := range vectors {
+		for j := range seeds {
+			jobs <- job{individual: i, game: j}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := range generation.Population {
+		var sum int
+		for _, l := range lines[i] {
+			sum += l
+		}
+		generation.Population[i].Fitness = float64(sum) / float64(len(seeds))
+	}
+
+	// Ties keep the order of the individuals, so that the elite of a generation are the first of the next one.
+	sort.SliceStable(generation.Population, func(i, j int) bool {
+		return generation.Population[i].Fitness > generation.Population[j].Fitness
+	})
+
+	return generation, nil
+}
+
+// play plays a game with the given seed, in which the AI evaluates boards with the given evaluator,
+// and returns the number of lines cleared in it.
+func play(config Config, evaluator *ai.Evaluator, seed int64) int {
+	a := ai.NewWithSize(config.Width, config.Height)
+	a.SetEvaluator(evaluator)
+	a.SetSeed(seed)
+	// The games are already played in parallel.
+	a.SetWorkers(1)
+
+	// The randomizer name has been validated.
+	randomizer, _ := tetris.NewRandomizer(config.Randomizer, rand.New(rand.NewSource(seed)))
+	game := player.NewGame(tetris.NewBoardWithSize(config.Width, config.Height), a, randomizer, 1)
+
+	for i := 0; i < config.MaxTetrominoes; i++ {
+		if err := game.Play(); err != nil {
+			break
+		}
+	}
+
+	return game.Board().ClearedLines()
+}
+
+// load reads the generation saved in the checkpoint file with the given path.
+func load(path string) (Generation, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Generation{}, err
+	}
+
+	var generation Generation
+	if err := json.Unmarshal(data, &generation); err != nil {
+		return Generation{}, fmt.Errorf("could not parse checkpoint %s: %s", path, err)
+	}
+	if len(generation.Population) == 0 {
+		return Generation{}, fmt.Errorf("checkpoint %s has no population", path)
+	}
+
+	return generation, nil
+}
+
+// save writes the generation to the checkpoint file with the given path.
+// The generation is first written to a temporary file, so that the previous checkpoint is not lost if writing fails.
+func save(path string, generation Generation) error {
+	data, err := json.MarshalIndent(generation, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	temporary := path + ".tmp"
+	if err := ioutil.WriteFile(temporary, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, path)
+}
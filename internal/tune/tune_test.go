@@ -0,0 +1,164 @@
+package tune_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ozhi/tetris-ai/internal/tune"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func smallConfig() tune.Config {
+	config := tune.DefaultConfig()
+	config.Population = 4
+	config.Generations = 3
+	config.Elite = 1
+	config.TournamentSize = 2
+	config.Games = 2
+	config.MaxTetrominoes = 30
+	config.Width = 6
+	config.Height = 12
+	config.Workers = 2
+	return config
+}
+
+func TestRunReportsEveryGeneration(t *testing.T) {
+	var generations []tune.Generation
+	best, err := tune.Run(smallConfig(), func(generation tune.Generation) {
+		generations = append(generations, generation)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, generations, 3)
+	for i, generation := range generations {
+		assert.Equal(t, i, generation.Number)
+		assert.Len(t, generation.Population, 4)
+		for j := 1; j < len(generation.Population); j++ {
+			assert.True(t, generation.Population[j-1].Fitness >= generation.Population[j].Fitness)
+		}
+	}
+	assert.Equal(t, generations[2].Best(), best)
+	assert.Len(t, best.Weights, len(smallConfig().Features))
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	config := smallConfig()
+
+	first, err := tune.Run(config, nil)
+	require.NoError(t, err)
+
+	// The games are played in a different order by more workers, but their results are the same.
+	config.Workers = 4
+	second, err := tune.Run(config, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tune")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, method := range tune.MethodNames() {
+		config := smallConfig()
+		config.Method = method
+
+		uninterrupted, err := tune.Run(config, nil)
+		require.NoError(t, err)
+
+		config.Checkpoint = filepath.Join(dir, method+".json")
+		config.Resume = true
+
+		// The first run is interrupted after two generations, the second one only plays the last generation.
+		config.Generations = 2
+		_, err = tune.Run(config, nil)
+		require.NoError(t, err)
+
+		config.Generations = 3
+		var resumed []int
+		best, err := tune.Run(config, func(generation tune.Generation) {
+			resumed = append(resumed, generation.Number)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{2}, resumed, method)
+		assert.True(t, reflect.DeepEqual(uninterrupted, best), "%s: expected %v, got %v", method, uninterrupted, best)
+	}
+}
+
+func TestRunWithCrossEntropyRefitsDistribution(t *testing.T) {
+	config := smallConfig()
+	config.Method = tune.MethodCrossEntropy
+	config.EliteFraction = 0.25
+	config.Noise = 1
+	config.NoiseDecay = 0.5
+
+	var generations []tune.Generation
+	_, err := tune.Run(config, func(generation tune.Generation) {
+		generations = append(generations, generation)
+	})
+	require.NoError(t, err)
+
+	for _, generation := range generations {
+		noise := 1 - 0.5*float64(generation.Number)
+		if noise < 0 {
+			noise = 0
+		}
+
+		// The elite fraction is a single individual, so the mean is its weights and the variance is only the noise.
+		for _, name := range config.Features {
+			assert.Equal(t, generation.Best().Weights[name], generation.Mean[name], name)
+			assert.InDelta(t, noise, generation.Variance[name], 1e-12, name)
+		}
+	}
+}
+
+func TestRunWithCrossEntropyAndLargeVariance(t *testing.T) {
+	// Szita and Lőrincz's variance and noise sample large weights, which are normalized before playing with them.
+	config := smallConfig()
+	config.Method = tune.MethodCrossEntropy
+	config.InitialVariance = 100
+	config.Noise = 4
+
+	best, err := tune.Run(config, nil)
+	require.NoError(t, err)
+
+	var length float64
+	for _, weight := range best.Weights {
+		length += weight * weight
+	}
+	assert.InDelta(t, 1, length, 1e-9)
+}
+
+func TestRunRejectsInvalidConfig(t *testing.T) {
+	configs := []func(*tune.Config){
+		func(c *tune.Config) { c.Features = nil },
+		func(c *tune.Config) { c.Features = []string{"unknown"} },
+		func(c *tune.Config) { c.Randomizer = "unknown" },
+		func(c *tune.Config) { c.Width = 2 },
+		func(c *tune.Config) { c.Height = 0 },
+		func(c *tune.Config) { c.Population = 1 },
+		func(c *tune.Config) { c.Elite = 4 },
+		func(c *tune.Config) { c.TournamentSize = 5 },
+		func(c *tune.Config) { c.MutationRate = 2 },
+		func(c *tune.Config) { c.Games = 0 },
+		func(c *tune.Config) { c.Workers = 0 },
+		func(c *tune.Config) { c.Method = "unknown" },
+		func(c *tune.Config) { c.Method, c.EliteFraction = tune.MethodCrossEntropy, 0 },
+		func(c *tune.Config) { c.Method, c.InitialVariance = tune.MethodCrossEntropy, 0 },
+		func(c *tune.Config) { c.Method, c.Noise = tune.MethodCrossEntropy, -1 },
+	}
+
+	for i, change := range configs {
+		config := smallConfig()
+		change(&config)
+
+		_, err := tune.Run(config, nil)
+		assert.Error(t, err, "config %d", i)
+	}
+}
@@ -3,26 +3,250 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/ozhi/tetris-ai/internal/ai"
 	"github.com/ozhi/tetris-ai/internal/cli"
 	"github.com/ozhi/tetris-ai/internal/gui"
+	"github.com/ozhi/tetris-ai/internal/player"
+	"github.com/ozhi/tetris-ai/internal/tetris"
+	"github.com/ozhi/tetris-ai/internal/tune"
 )
 
-var useCli bool
+var (
+	useCli      bool
+	boardWidth  int
+	boardHeight int
+	randomizer  string
+	playerName  string
+	seed        int64
+	seedIsSet   bool
+	weightsName string
+	weightsFile string
+	weights     string
+	depth       int
+	moveTime    time.Duration
+	previews    int
+	hints       bool
+
+	beamWidth        int
+	mctsIterations   int
+	mctsRolloutDepth int
+	mctsExploration  float64
+)
 
 func init() {
 	flag.BoolVar(&useCli, "cli", false, "should the command-line interface be used")
+	flag.IntVar(&boardWidth, "width", tetris.DefaultBoardWidth, "number of columns of the board")
+	flag.IntVar(&boardHeight, "height", tetris.DefaultBoardHeight, "number of rows of the board")
+	flag.StringVar(&randomizer, "randomizer", tetris.RandomizerUniform,
+		fmt.Sprintf("randomizer that deals the tetrominoes, one of: %s", strings.Join(tetris.RandomizerNames(), ", ")))
+	flag.StringVar(&playerName, "player", ai.Name,
+		fmt.Sprintf("player that places the tetrominoes, one of: %s", strings.Join(player.Names(), ", ")))
+	flag.StringVar(&weightsName, "evaluator", ai.WeightsDefault,
+		fmt.Sprintf("weights of the AI's features, one of: %s", strings.Join(ai.WeightsNames(), ", ")))
+	flag.StringVar(&weightsFile, "weights-file", "",
+		"JSON file with the weights of the AI's features, which replace the ones of -evaluator")
+	flag.StringVar(&weights, "weights", "",
+		fmt.Sprintf("comma-separated name=weight pairs that override the AI's feature weights, names are: %s",
+			strings.Join(ai.FeatureNames(), ", ")))
+	flag.IntVar(&depth, "depth", 1, "number of unknown tetrominoes the AI searches, or the maximum number with -move-time")
+	flag.DurationVar(&moveTime, "move-time", 0,
+		"time in which the AI searches each move, deepening the search up to -depth (by default, there is no limit)")
+	flag.IntVar(&beamWidth, "beam-width", ai.DefaultBeamWidth,
+		"number of best boards the beam player keeps after placing each known tetromino")
+	flag.IntVar(&mctsIterations, "mcts-iterations", ai.DefaultIterations,
+		"number of iterations of the mcts player for each move, or the maximum number with -move-time (0 to only limit it with -move-time)")
+	flag.IntVar(&mctsRolloutDepth, "mcts-rollout-depth", ai.DefaultRolloutDepth,
+		"number of tetrominoes the mcts player drops greedily in each rollout")
+	flag.Float64Var(&mctsExploration, "mcts-exploration", ai.DefaultExploration,
+		"exploration constant of the mcts player, with which it searches less visited placements")
+	flag.IntVar(&previews, "previews", 1, "number of tetrominoes shown after the current one, which the player knows")
+	flag.BoolVar(&hints, "hints", false,
+		"should the AI suggest moves: outlined on the board in human mode (toggled with G) or printed in the command line")
+	flag.Int64Var(&seed, "seed", 0, "seed of the game, with which it can be replayed (by default, the time is used)")
 	flag.Parse()
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedIsSet = true
+		}
+	})
 }
 
 func main() {
+	if flag.Arg(0) == "tune" {
+		if err := runTune(flag.Args()[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := setEvaluator(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if err := tetris.ValidateBoardSize(boardWidth, boardHeight); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if depth < 0 || moveTime < 0 {
+		fmt.Println("-depth and -move-time must not be negative")
+		return
+	}
+	ai.SetDefaultDepth(depth)
+	ai.SetDefaultTimeBudget(moveTime)
+
+	if beamWidth < 1 {
+		fmt.Println("-beam-width must be positive")
+		return
+	}
+	ai.SetDefaultBeamWidth(beamWidth)
+
+	if mctsIterations < 0 || mctsRolloutDepth < 0 || !(mctsExploration >= 0) {
+		fmt.Println("-mcts-iterations, -mcts-rollout-depth and -mcts-exploration must not be negative")
+		return
+	}
+	ai.SetDefaultMCTS(mctsIterations, mctsRolloutDepth, mctsExploration)
+
 	if useCli {
-		cli.New().Start()
+		cli := cli.NewWithSize(boardWidth, boardHeight)
+		if seedIsSet {
+			cli.SetSeed(seed)
+		}
+		if err := cli.SetRandomizer(randomizer); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := cli.SetPlayer(playerName); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := cli.SetPreviews(previews); err != nil {
+			fmt.Println(err)
+			return
+		}
+		cli.SetHints(hints)
+
+		cli.Start()
 		return
 	}
 
-	err := gui.New().Start()
+	gui := gui.NewWithSize(boardWidth, boardHeight)
+	if seedIsSet {
+		gui.SetSeed(seed)
+	}
+	if err := gui.SetRandomizer(randomizer); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := gui.SetPlayer(playerName); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := gui.SetPreviews(previews); err != nil {
+		fmt.Println(err)
+		return
+	}
+	gui.SetHints(hints)
+
+	err := gui.Start()
 	if err != nil {
 		fmt.Println(err)
 	}
 }
+
+// setEvaluator sets the AI's default evaluator with the weights from the -evaluator, -weights-file and -weights flags.
+func setEvaluator() error {
+	w, err := ai.NamedWeights(weightsName)
+	if err != nil {
+		return err
+	}
+
+	if weightsFile != "" {
+		loaded, err := ai.LoadWeights(weightsFile)
+		if err != nil {
+			return err
+		}
+		w = loaded
+	}
+
+	overrides, err := ai.ParseWeights(weights)
+	if err != nil {
+		return err
+	}
+	for name, weight := range overrides {
+		w[name] = weight
+	}
+
+	evaluator, err := ai.NewEvaluator(w)
+	if err != nil {
+		return err
+	}
+	ai.SetDefaultEvaluator(evaluator)
+
+	return nil
+}
+
+// runTune runs the tune subcommand with the given arguments:
+// it tunes the weights of the AI's features with a genetic algorithm or the cross-entropy method and saves the best ones.
+func runTune(args []string) error {
+	config := tune.DefaultConfig()
+
+	var (
+		flags    = flag.NewFlagSet("tune", flag.ExitOnError)
+		features = flags.String("features", strings.Join(config.Features, ","),
+			fmt.Sprintf("comma-separated features to tune, of: %s", strings.Join(ai.FeatureNames(), ", ")))
+		out = flags.String("out", "weights/tuned.json", "JSON file to which the best weights are saved")
+	)
+	flags.StringVar(&config.Method, "method", config.Method,
+		fmt.Sprintf("optimizer of the weights, one of: %s", strings.Join(tune.MethodNames(), ", ")))
+	flags.IntVar(&config.Population, "population", config.Population, "number of individuals in each generation")
+	flags.IntVar(&config.Generations, "generations", config.Generations, "number of generations")
+	flags.IntVar(&config.Elite, "elite", config.Elite, "number of fittest individuals kept in the next generation")
+	flags.IntVar(&config.TournamentSize, "tournament", config.TournamentSize, "number of individuals in each tournament")
+	flags.Float64Var(&config.MutationRate, "mutation-rate", config.MutationRate, "probability of mutating each weight")
+	flags.Float64Var(&config.MutationScale, "mutation-scale", config.MutationScale, "standard deviation of mutations")
+	flags.Float64Var(&config.EliteFraction, "elite-fraction", config.EliteFraction,
+		"fraction of fittest individuals to which the cross-entropy method refits the distribution")
+	flags.Float64Var(&config.InitialVariance, "variance", config.InitialVariance,
+		"initial variance of each weight in the cross-entropy method")
+	flags.Float64Var(&config.Noise, "noise", config.Noise, "noise added to the variance in the cross-entropy method")
+	flags.Float64Var(&config.NoiseDecay, "noise-decay", config.NoiseDecay, "decrease of the noise every generation")
+	flags.IntVar(&config.Games, "games", config.Games, "number of games each individual plays in each generation")
+	flags.IntVar(&config.MaxTetrominoes, "tetrominoes", config.MaxTetrominoes, "maximum number of tetrominoes in each game")
+	flags.IntVar(&config.Width, "width", config.Width, "number of columns of the boards")
+	flags.IntVar(&config.Height, "height", config.Height, "number of rows of the boards")
+	flags.StringVar(&config.Randomizer, "randomizer", config.Randomizer,
+		fmt.Sprintf("randomizer that deals the tetrominoes, one of: %s", strings.Join(tetris.RandomizerNames(), ", ")))
+	flags.Int64Var(&config.Seed, "seed", config.Seed, "seed of the tuning, with which it can be repeated")
+	flags.IntVar(&config.Workers, "workers", config.Workers, "number of games played in parallel")
+	flags.StringVar(&config.Checkpoint, "checkpoint", "tune-checkpoint.json", "JSON file to which each generation is saved")
+	flags.BoolVar(&config.Resume, "resume", false, "continue after the generation saved in the checkpoint file")
+	flags.Parse(args)
+
+	config.Features = strings.Split(*features, ",")
+
+	best, err := tune.Run(config, func(generation tune.Generation) {
+		fmt.Printf("Generation %d: best fitness %.2f, weights %v\n",
+			generation.Number, generation.Best().Fitness, generation.Best().Weights)
+		if generation.Mean != nil {
+			fmt.Printf("  mean %v\n  variance %v\n", generation.Mean, generation.Variance)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ai.SaveWeights(*out, best.Weights); err != nil {
+		return err
+	}
+	fmt.Printf("Best weights saved to %s, use them with -weights-file %s\n", *out, *out)
+
+	return nil
+}